@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS refresh_jobs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    countries_processed INT NOT NULL DEFAULT 0,
+    countries_failed INT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+
+    INDEX idx_status (status)
+);
@@ -0,0 +1 @@
+ALTER TABLE refresh_jobs DROP COLUMN source;
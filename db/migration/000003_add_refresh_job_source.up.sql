@@ -0,0 +1 @@
+ALTER TABLE refresh_jobs ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'live';
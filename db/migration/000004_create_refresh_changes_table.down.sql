@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS refresh_changes;
+
+ALTER TABLE refresh_jobs
+    DROP COLUMN countries_added,
+    DROP COLUMN countries_removed,
+    DROP COLUMN countries_modified;
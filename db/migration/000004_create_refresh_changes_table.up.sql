@@ -0,0 +1,16 @@
+ALTER TABLE refresh_jobs
+    ADD COLUMN countries_added INT NOT NULL DEFAULT 0,
+    ADD COLUMN countries_removed INT NOT NULL DEFAULT 0,
+    ADD COLUMN countries_modified INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refresh_changes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NOT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    field VARCHAR(50),
+    old_value TEXT,
+    new_value TEXT,
+
+    INDEX idx_job (job_id)
+);
@@ -0,0 +1 @@
+ALTER TABLE countries ADD COLUMN stale_since TIMESTAMP NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS country_currencies;
+
+DROP TABLE IF EXISTS currencies;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(255),
+    symbol VARCHAR(50)
+);
+
+CREATE TABLE IF NOT EXISTS country_currencies (
+    country_id BIGINT NOT NULL,
+    currency_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, currency_code),
+    INDEX idx_currency_code (currency_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
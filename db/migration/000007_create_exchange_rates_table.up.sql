@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency_code VARCHAR(10) NOT NULL,
+    base_code VARCHAR(10) NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    rate DECIMAL(20, 6) NOT NULL,
+
+    PRIMARY KEY (currency_code, base_code, fetched_at),
+    INDEX idx_fetched_at (fetched_at)
+);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS country_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    country_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    capital VARCHAR(255) NULL,
+    region VARCHAR(100) NULL,
+    population BIGINT NOT NULL,
+    currency_code VARCHAR(10) NULL,
+    exchange_rate DECIMAL(20, 6) NULL,
+    estimated_gdp DECIMAL(30, 2) NULL,
+    flag_url TEXT NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_to TIMESTAMP NULL,
+
+    INDEX idx_name_valid (country_name, valid_from),
+    INDEX idx_valid (valid_from, valid_to)
+);
+
+INSERT INTO country_history (
+    country_id, country_name, capital, region, population,
+    currency_code, exchange_rate, estimated_gdp, flag_url, valid_from
+)
+SELECT c.id, c.name, c.capital, c.region, c.population,
+    c.currency_code, c.exchange_rate, c.estimated_gdp, c.flag_url,
+    COALESCE(c.last_refreshed_at, NOW())
+FROM countries c
+WHERE NOT EXISTS (
+    SELECT 1 FROM country_history h
+    WHERE h.country_name = c.name AND h.valid_to IS NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE countries DROP COLUMN gdp_multiplier;
+ALTER TABLE countries DROP COLUMN gdp_strategy;
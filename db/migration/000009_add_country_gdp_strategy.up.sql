@@ -0,0 +1,2 @@
+ALTER TABLE countries ADD COLUMN gdp_strategy VARCHAR(20) NULL;
+ALTER TABLE countries ADD COLUMN gdp_multiplier DECIMAL(12, 4) NULL;
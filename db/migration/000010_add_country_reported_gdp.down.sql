@@ -0,0 +1,2 @@
+ALTER TABLE countries DROP COLUMN reported_gdp_year;
+ALTER TABLE countries DROP COLUMN reported_gdp;
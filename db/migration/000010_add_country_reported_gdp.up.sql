@@ -0,0 +1,2 @@
+ALTER TABLE countries ADD COLUMN reported_gdp DECIMAL(30, 2) NULL;
+ALTER TABLE countries ADD COLUMN reported_gdp_year INT NULL;
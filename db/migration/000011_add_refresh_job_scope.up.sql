@@ -0,0 +1 @@
+ALTER TABLE refresh_jobs ADD COLUMN scope VARCHAR(255) NULL;
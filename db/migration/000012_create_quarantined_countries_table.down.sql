@@ -0,0 +1,3 @@
+ALTER TABLE refresh_jobs DROP COLUMN countries_quarantined;
+
+DROP TABLE IF EXISTS quarantined_countries;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS quarantined_countries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP NULL,
+
+    INDEX idx_status (status),
+    INDEX idx_country_name (country_name)
+);
+
+ALTER TABLE refresh_jobs ADD COLUMN countries_quarantined INT NOT NULL DEFAULT 0;
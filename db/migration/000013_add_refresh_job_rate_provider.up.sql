@@ -0,0 +1 @@
+ALTER TABLE refresh_jobs ADD COLUMN rate_provider VARCHAR(50) NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE countries DROP INDEX idx_numeric_code;
+ALTER TABLE countries DROP INDEX idx_alpha3_code;
+ALTER TABLE countries DROP INDEX idx_alpha2_code;
+ALTER TABLE countries DROP COLUMN numeric_code;
+ALTER TABLE countries DROP COLUMN alpha3_code;
+ALTER TABLE countries DROP COLUMN alpha2_code;
@@ -0,0 +1,6 @@
+ALTER TABLE countries ADD COLUMN alpha2_code VARCHAR(2) NULL;
+ALTER TABLE countries ADD COLUMN alpha3_code VARCHAR(3) NULL;
+ALTER TABLE countries ADD COLUMN numeric_code VARCHAR(3) NULL;
+ALTER TABLE countries ADD UNIQUE INDEX idx_alpha2_code (alpha2_code);
+ALTER TABLE countries ADD UNIQUE INDEX idx_alpha3_code (alpha3_code);
+ALTER TABLE countries ADD UNIQUE INDEX idx_numeric_code (numeric_code);
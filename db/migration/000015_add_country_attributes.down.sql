@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS country_languages;
+DROP TABLE IF EXISTS languages;
+
+ALTER TABLE countries DROP INDEX idx_subregion;
+ALTER TABLE countries DROP COLUMN borders;
+ALTER TABLE countries DROP COLUMN calling_codes;
+ALTER TABLE countries DROP COLUMN timezones;
+ALTER TABLE countries DROP COLUMN longitude;
+ALTER TABLE countries DROP COLUMN latitude;
+ALTER TABLE countries DROP COLUMN area;
+ALTER TABLE countries DROP COLUMN subregion;
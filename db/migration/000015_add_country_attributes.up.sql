@@ -0,0 +1,26 @@
+ALTER TABLE countries ADD COLUMN subregion VARCHAR(100) NULL;
+ALTER TABLE countries ADD COLUMN area DECIMAL(15, 2) NULL;
+ALTER TABLE countries ADD COLUMN latitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN longitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN timezones TEXT NULL;
+ALTER TABLE countries ADD COLUMN calling_codes TEXT NULL;
+ALTER TABLE countries ADD COLUMN borders TEXT NULL;
+ALTER TABLE countries ADD INDEX idx_subregion (subregion);
+
+CREATE TABLE IF NOT EXISTS languages (
+    code VARCHAR(10) PRIMARY KEY,
+    iso639_1 VARCHAR(2) NULL,
+    name VARCHAR(255) NULL,
+
+    INDEX idx_iso639_1 (iso639_1)
+);
+
+CREATE TABLE IF NOT EXISTS country_languages (
+    country_id BIGINT NOT NULL,
+    language_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, language_code),
+    INDEX idx_language_code (language_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE countries DROP COLUMN capital_longitude;
+ALTER TABLE countries DROP COLUMN capital_latitude;
@@ -0,0 +1,2 @@
+ALTER TABLE countries ADD COLUMN capital_latitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN capital_longitude DECIMAL(9, 6) NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS flags (
+    url_hash CHAR(64) PRIMARY KEY,
+    url TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    etag VARCHAR(255) NULL,
+    last_modified VARCHAR(64) NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
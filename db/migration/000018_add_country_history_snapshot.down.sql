@@ -0,0 +1,19 @@
+ALTER TABLE country_history DROP INDEX idx_country_valid;
+ALTER TABLE country_history DROP COLUMN languages;
+ALTER TABLE country_history DROP COLUMN currencies;
+ALTER TABLE country_history DROP COLUMN capital_longitude;
+ALTER TABLE country_history DROP COLUMN capital_latitude;
+ALTER TABLE country_history DROP COLUMN borders;
+ALTER TABLE country_history DROP COLUMN calling_codes;
+ALTER TABLE country_history DROP COLUMN timezones;
+ALTER TABLE country_history DROP COLUMN longitude;
+ALTER TABLE country_history DROP COLUMN latitude;
+ALTER TABLE country_history DROP COLUMN area;
+ALTER TABLE country_history DROP COLUMN subregion;
+ALTER TABLE country_history DROP COLUMN numeric_code;
+ALTER TABLE country_history DROP COLUMN alpha3_code;
+ALTER TABLE country_history DROP COLUMN alpha2_code;
+ALTER TABLE country_history DROP COLUMN reported_gdp_year;
+ALTER TABLE country_history DROP COLUMN reported_gdp;
+ALTER TABLE country_history DROP COLUMN gdp_multiplier;
+ALTER TABLE country_history DROP COLUMN gdp_strategy;
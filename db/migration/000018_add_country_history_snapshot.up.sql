@@ -0,0 +1,48 @@
+ALTER TABLE country_history ADD COLUMN gdp_strategy VARCHAR(20) NULL;
+ALTER TABLE country_history ADD COLUMN gdp_multiplier DECIMAL(12, 4) NULL;
+ALTER TABLE country_history ADD COLUMN reported_gdp DECIMAL(30, 2) NULL;
+ALTER TABLE country_history ADD COLUMN reported_gdp_year INT NULL;
+ALTER TABLE country_history ADD COLUMN alpha2_code VARCHAR(2) NULL;
+ALTER TABLE country_history ADD COLUMN alpha3_code VARCHAR(3) NULL;
+ALTER TABLE country_history ADD COLUMN numeric_code VARCHAR(3) NULL;
+ALTER TABLE country_history ADD COLUMN subregion VARCHAR(100) NULL;
+ALTER TABLE country_history ADD COLUMN area DECIMAL(15, 2) NULL;
+ALTER TABLE country_history ADD COLUMN latitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN longitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN timezones TEXT NULL;
+ALTER TABLE country_history ADD COLUMN calling_codes TEXT NULL;
+ALTER TABLE country_history ADD COLUMN borders TEXT NULL;
+ALTER TABLE country_history ADD COLUMN capital_latitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN capital_longitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN currencies TEXT NULL;
+ALTER TABLE country_history ADD COLUMN languages TEXT NULL;
+ALTER TABLE country_history ADD INDEX idx_country_valid (country_id, valid_from);
+
+-- open versions take the columns they lacked from the current row
+UPDATE country_history h
+JOIN countries c ON c.id = h.country_id
+SET h.gdp_strategy = c.gdp_strategy,
+    h.gdp_multiplier = c.gdp_multiplier,
+    h.reported_gdp = c.reported_gdp,
+    h.reported_gdp_year = c.reported_gdp_year,
+    h.alpha2_code = c.alpha2_code,
+    h.alpha3_code = c.alpha3_code,
+    h.numeric_code = c.numeric_code,
+    h.subregion = c.subregion,
+    h.area = c.area,
+    h.latitude = c.latitude,
+    h.longitude = c.longitude,
+    h.timezones = c.timezones,
+    h.calling_codes = c.calling_codes,
+    h.borders = c.borders,
+    h.capital_latitude = c.capital_latitude,
+    h.capital_longitude = c.capital_longitude,
+    h.currencies = (
+        SELECT CONCAT('["', GROUP_CONCAT(currency_code ORDER BY position SEPARATOR '","'), '"]')
+        FROM country_currencies WHERE country_id = c.id
+    ),
+    h.languages = (
+        SELECT CONCAT('["', GROUP_CONCAT(language_code ORDER BY position SEPARATOR '","'), '"]')
+        FROM country_languages WHERE country_id = c.id
+    )
+WHERE h.valid_to IS NULL;
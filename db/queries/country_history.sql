@@ -0,0 +1,46 @@
+-- name: CreateCountryHistory :exec
+INSERT INTO country_history (
+    country_id, country_name, capital, region, population,
+    currency_code, exchange_rate, estimated_gdp, flag_url, valid_from,
+    gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year,
+    alpha2_code, alpha3_code, numeric_code, subregion, area, latitude,
+    longitude, timezones, calling_codes, borders, capital_latitude,
+    capital_longitude, currencies, languages
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+    ?, ?, ?, ?, ?
+);
+
+-- name: CloseCountryHistory :exec
+UPDATE country_history
+SET valid_to = sqlc.arg(valid_to)
+WHERE country_id = sqlc.arg(country_id) AND valid_to IS NULL;
+
+-- name: ListCountriesAsOf :many
+SELECT * FROM country_history
+WHERE valid_from <= sqlc.arg(as_of)
+  AND (valid_to IS NULL OR valid_to > sqlc.arg(as_of))
+ORDER BY country_id, id;
+
+-- name: GetCountryAsOf :one
+SELECT * FROM country_history
+WHERE country_id = sqlc.arg(country_id)
+  AND valid_from <= sqlc.arg(as_of)
+  AND (valid_to IS NULL OR valid_to > sqlc.arg(as_of))
+ORDER BY valid_from DESC
+LIMIT 1;
+
+-- name: GetHistoryCountryID :one
+SELECT country_id FROM country_history
+WHERE country_id IS NOT NULL
+  AND (LOWER(country_name) = LOWER(sqlc.arg(key))
+    OR alpha2_code = UPPER(sqlc.arg(key))
+    OR alpha3_code = UPPER(sqlc.arg(key))
+    OR numeric_code = sqlc.arg(key))
+ORDER BY valid_from DESC
+LIMIT 1;
+
+-- name: ListCountryHistory :many
+SELECT * FROM country_history
+WHERE country_id = ?
+ORDER BY valid_from;
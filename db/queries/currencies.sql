@@ -0,0 +1,28 @@
+-- name: UpsertCurrency :exec
+INSERT INTO currencies (code, name, symbol) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    name = VALUES(name),
+    symbol = VALUES(symbol);
+
+-- name: CreateCountryCurrency :exec
+INSERT INTO country_currencies (country_id, currency_code, position)
+VALUES (?, ?, ?);
+
+-- name: ListCountryCurrencies :many
+SELECT c.code, c.name, c.symbol
+FROM country_currencies cc
+JOIN currencies c ON c.code = cc.currency_code
+WHERE cc.country_id = ?
+ORDER BY cc.position;
+
+-- name: ListCurrencyCodesByCountry :many
+SELECT country_id, currency_code
+FROM country_currencies
+ORDER BY country_id, position;
+
+-- name: ListCountryIDsByCurrency :many
+SELECT country_id FROM country_currencies
+WHERE currency_code = ?;
+
+-- name: ListCountryIDs :many
+SELECT id, name FROM countries;
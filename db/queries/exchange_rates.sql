@@ -0,0 +1,16 @@
+-- name: CreateExchangeRate :exec
+INSERT IGNORE INTO exchange_rates (currency_code, base_code, fetched_at, rate)
+VALUES (?, ?, ?, ?);
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+WHERE currency_code = sqlc.arg(currency_code)
+  AND base_code = sqlc.arg(base_code)
+  AND fetched_at >= sqlc.arg(from_time)
+  AND fetched_at <= sqlc.arg(to_time)
+ORDER BY fetched_at;
+
+-- name: ListLatestExchangeRates :many
+SELECT * FROM exchange_rates
+WHERE fetched_at = (SELECT MAX(fetched_at) FROM exchange_rates)
+ORDER BY currency_code;
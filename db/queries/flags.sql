@@ -0,0 +1,20 @@
+-- name: GetFlag :one
+SELECT * FROM flags
+WHERE url_hash = ?;
+
+-- name: UpsertFlag :exec
+INSERT INTO flags (
+    url_hash, url, content_hash, content_type, size, etag, last_modified, fetched_at, checked_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    content_hash = VALUES(content_hash),
+    content_type = VALUES(content_type),
+    size = VALUES(size),
+    etag = VALUES(etag),
+    last_modified = VALUES(last_modified),
+    fetched_at = VALUES(fetched_at),
+    checked_at = VALUES(checked_at);
+
+-- name: TouchFlag :exec
+UPDATE flags SET checked_at = ?
+WHERE url_hash = ?;
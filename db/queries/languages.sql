@@ -0,0 +1,27 @@
+-- name: UpsertLanguage :exec
+INSERT INTO languages (code, iso639_1, name) VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE
+    iso639_1 = COALESCE(VALUES(iso639_1), iso639_1),
+    name = VALUES(name);
+
+-- name: CreateCountryLanguage :exec
+INSERT INTO country_languages (country_id, language_code, position)
+VALUES (?, ?, ?);
+
+-- name: ListCountryLanguages :many
+SELECT l.code, l.iso639_1, l.name
+FROM country_languages cl
+JOIN languages l ON l.code = cl.language_code
+WHERE cl.country_id = ?
+ORDER BY cl.position;
+
+-- name: ListLanguageCodesByCountry :many
+SELECT country_id, language_code
+FROM country_languages
+ORDER BY country_id, position;
+
+-- name: ListCountryIDsByLanguage :many
+SELECT DISTINCT cl.country_id
+FROM country_languages cl
+JOIN languages l ON l.code = cl.language_code
+WHERE l.code = sqlc.arg(language) OR l.iso639_1 = sqlc.arg(language) OR LOWER(l.name) = LOWER(sqlc.arg(language));
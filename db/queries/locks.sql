@@ -0,0 +1,8 @@
+-- name: GetLock :one
+SELECT GET_LOCK(sqlc.arg(name), sqlc.arg(timeout)) AS acquired;
+
+-- name: ReleaseLock :exec
+DO RELEASE_LOCK(?);
+
+-- name: IsFreeLock :one
+SELECT IS_FREE_LOCK(?) AS free;
@@ -0,0 +1,29 @@
+-- name: CreateQuarantinedCountry :exec
+INSERT INTO quarantined_countries (job_id, country_name, reason, payload)
+VALUES (?, ?, ?, ?);
+
+-- name: DeletePendingQuarantine :exec
+DELETE FROM quarantined_countries
+WHERE status = 'pending' AND country_name = ?;
+
+-- name: GetQuarantinedCountry :one
+SELECT * FROM quarantined_countries
+WHERE id = ?;
+
+-- name: ListQuarantinedCountries :many
+SELECT * FROM quarantined_countries
+WHERE status = ?
+ORDER BY id;
+
+-- name: ListAllQuarantinedCountries :many
+SELECT * FROM quarantined_countries
+ORDER BY id;
+
+-- name: ListAcceptedPayloads :many
+SELECT country_name, payload FROM quarantined_countries
+WHERE status = 'accepted';
+
+-- name: AcceptQuarantinedCountry :exec
+UPDATE quarantined_countries
+SET status = 'accepted', accepted_at = NOW()
+WHERE id = ?;
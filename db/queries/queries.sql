@@ -1,8 +1,11 @@
 -- name: UpsertCountry :exec
 INSERT INTO countries (
     name, capital, region, population, 
-    currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+    currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at,
+    gdp_strategy, gdp_multiplier, alpha2_code, alpha3_code, numeric_code,
+    subregion, area, latitude, longitude, timezones, calling_codes, borders,
+    capital_latitude, capital_longitude
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
     capital = VALUES(capital),
     region = VALUES(region),
@@ -11,12 +14,32 @@ ON DUPLICATE KEY UPDATE
     exchange_rate = VALUES(exchange_rate),
     estimated_gdp = VALUES(estimated_gdp),
     flag_url = VALUES(flag_url),
-    last_refreshed_at = NOW();
+    last_refreshed_at = NOW(),
+    stale_since = NULL,
+    gdp_strategy = VALUES(gdp_strategy),
+    gdp_multiplier = VALUES(gdp_multiplier),
+    alpha2_code = VALUES(alpha2_code),
+    alpha3_code = VALUES(alpha3_code),
+    numeric_code = VALUES(numeric_code),
+    subregion = VALUES(subregion),
+    area = VALUES(area),
+    latitude = VALUES(latitude),
+    longitude = VALUES(longitude),
+    timezones = VALUES(timezones),
+    calling_codes = VALUES(calling_codes),
+    borders = VALUES(borders),
+    capital_latitude = VALUES(capital_latitude),
+    capital_longitude = VALUES(capital_longitude);
 
 -- name: GetAllCountries :many
 SELECT * FROM countries
 ORDER BY id;
 
+-- name: GetCountryByCode :one
+SELECT * FROM countries
+WHERE alpha2_code = sqlc.arg(code) OR alpha3_code = sqlc.arg(code) OR numeric_code = sqlc.arg(code)
+LIMIT 1;
+
 -- name: GetCountryByName :one
 SELECT * FROM countries
 WHERE LOWER(name) = LOWER(?);
@@ -37,3 +60,13 @@ SELECT * FROM countries
 WHERE estimated_gdp IS NOT NULL
 ORDER BY estimated_gdp DESC
 LIMIT ?;
+
+-- name: RenameCountry :exec
+UPDATE countries
+SET name = ?
+WHERE id = ?;
+
+-- name: UpdateReportedGDP :exec
+UPDATE countries
+SET reported_gdp = ?, reported_gdp_year = ?, last_refreshed_at = last_refreshed_at
+WHERE id = ?;
@@ -0,0 +1,51 @@
+-- name: CreateRefreshJob :execresult
+INSERT INTO refresh_jobs (status, source, scope) VALUES ('queued', ?, ?);
+
+-- name: GetRefreshJob :one
+SELECT * FROM refresh_jobs
+WHERE id = ?;
+
+-- name: MarkRefreshJobRunning :exec
+UPDATE refresh_jobs
+SET status = 'running', started_at = NOW()
+WHERE id = ?;
+
+-- name: UpdateRefreshJobProgress :exec
+UPDATE refresh_jobs
+SET countries_processed = ?, countries_failed = ?
+WHERE id = ?;
+
+-- name: FinishRefreshJob :exec
+UPDATE refresh_jobs
+SET status = ?, countries_processed = ?, countries_failed = ?,
+    countries_added = ?, countries_removed = ?, countries_modified = ?,
+    countries_quarantined = ?, rate_provider = ?, error_message = ?, finished_at = NOW()
+WHERE id = ?;
+
+-- name: GetActiveRefreshJob :one
+SELECT * FROM refresh_jobs
+WHERE status IN ('queued', 'running')
+ORDER BY status = 'running' DESC, id
+LIMIT 1;
+
+-- name: GetLastRateProvider :one
+SELECT rate_provider FROM refresh_jobs
+WHERE status = 'succeeded' AND rate_provider IS NOT NULL
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: FailInterruptedRefreshJobs :exec
+UPDATE refresh_jobs
+SET status = 'failed', error_message = 'interrupted before completion', finished_at = NOW()
+WHERE status = 'running'
+   OR (status = 'queued' AND created_at < sqlc.arg(queued_before));
+
+-- name: CreateRefreshChange :exec
+INSERT INTO refresh_changes (
+    job_id, country_name, change_type, field, old_value, new_value
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListRefreshChanges :many
+SELECT * FROM refresh_changes
+WHERE job_id = ?
+ORDER BY id;
@@ -9,7 +9,167 @@ CREATE TABLE IF NOT EXISTS countries (
     estimated_gdp DECIMAL(30, 2) NULL,
     flag_url TEXT NULL,
     last_refreshed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    stale_since TIMESTAMP NULL,
+    gdp_strategy VARCHAR(20) NULL,
+    gdp_multiplier DECIMAL(12, 4) NULL,
+    reported_gdp DECIMAL(30, 2) NULL,
+    reported_gdp_year INT NULL,
+    alpha2_code VARCHAR(2) NULL,
+    alpha3_code VARCHAR(3) NULL,
+    numeric_code VARCHAR(3) NULL,
+    subregion VARCHAR(100) NULL,
+    area DECIMAL(15, 2) NULL,
+    latitude DECIMAL(9, 6) NULL,
+    longitude DECIMAL(9, 6) NULL,
+    timezones TEXT NULL,
+    calling_codes TEXT NULL,
+    borders TEXT NULL,
+    capital_latitude DECIMAL(9, 6) NULL,
+    capital_longitude DECIMAL(9, 6) NULL,
     
     INDEX idx_region (region),
-    INDEX idx_currency (currency_code)
-);
\ No newline at end of file
+    INDEX idx_subregion (subregion),
+    INDEX idx_currency (currency_code),
+    UNIQUE INDEX idx_alpha2_code (alpha2_code),
+    UNIQUE INDEX idx_alpha3_code (alpha3_code),
+    UNIQUE INDEX idx_numeric_code (numeric_code)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_jobs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    countries_processed INT NOT NULL DEFAULT 0,
+    countries_failed INT NOT NULL DEFAULT 0,
+    error_message TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'live',
+    countries_added INT NOT NULL DEFAULT 0,
+    countries_removed INT NOT NULL DEFAULT 0,
+    countries_modified INT NOT NULL DEFAULT 0,
+    scope VARCHAR(255) NULL,
+    countries_quarantined INT NOT NULL DEFAULT 0,
+    rate_provider VARCHAR(50) NULL,
+
+    INDEX idx_status (status)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_changes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NOT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    field VARCHAR(50) NULL,
+    old_value TEXT NULL,
+    new_value TEXT NULL,
+
+    INDEX idx_job (job_id)
+);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(255) NULL,
+    symbol VARCHAR(50) NULL
+);
+
+CREATE TABLE IF NOT EXISTS country_currencies (
+    country_id BIGINT NOT NULL,
+    currency_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, currency_code),
+    INDEX idx_currency_code (currency_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS languages (
+    code VARCHAR(10) PRIMARY KEY,
+    iso639_1 VARCHAR(2) NULL,
+    name VARCHAR(255) NULL,
+
+    INDEX idx_iso639_1 (iso639_1)
+);
+
+CREATE TABLE IF NOT EXISTS country_languages (
+    country_id BIGINT NOT NULL,
+    language_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, language_code),
+    INDEX idx_language_code (language_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency_code VARCHAR(10) NOT NULL,
+    base_code VARCHAR(10) NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    rate DECIMAL(20, 6) NOT NULL,
+
+    PRIMARY KEY (currency_code, base_code, fetched_at),
+    INDEX idx_fetched_at (fetched_at)
+);
+
+CREATE TABLE IF NOT EXISTS country_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    country_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    capital VARCHAR(255) NULL,
+    region VARCHAR(100) NULL,
+    population BIGINT NOT NULL,
+    currency_code VARCHAR(10) NULL,
+    exchange_rate DECIMAL(20, 6) NULL,
+    estimated_gdp DECIMAL(30, 2) NULL,
+    flag_url TEXT NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_to TIMESTAMP NULL,
+    gdp_strategy VARCHAR(20) NULL,
+    gdp_multiplier DECIMAL(12, 4) NULL,
+    reported_gdp DECIMAL(30, 2) NULL,
+    reported_gdp_year INT NULL,
+    alpha2_code VARCHAR(2) NULL,
+    alpha3_code VARCHAR(3) NULL,
+    numeric_code VARCHAR(3) NULL,
+    subregion VARCHAR(100) NULL,
+    area DECIMAL(15, 2) NULL,
+    latitude DECIMAL(9, 6) NULL,
+    longitude DECIMAL(9, 6) NULL,
+    timezones TEXT NULL,
+    calling_codes TEXT NULL,
+    borders TEXT NULL,
+    capital_latitude DECIMAL(9, 6) NULL,
+    capital_longitude DECIMAL(9, 6) NULL,
+    currencies TEXT NULL,
+    languages TEXT NULL,
+
+    INDEX idx_name_valid (country_name, valid_from),
+    INDEX idx_country_valid (country_id, valid_from),
+    INDEX idx_valid (valid_from, valid_to)
+);
+
+CREATE TABLE IF NOT EXISTS quarantined_countries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP NULL,
+
+    INDEX idx_status (status),
+    INDEX idx_country_name (country_name)
+);
+
+CREATE TABLE IF NOT EXISTS flags (
+    url_hash CHAR(64) PRIMARY KEY,
+    url TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    etag VARCHAR(255) NULL,
+    last_modified VARCHAR(64) NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,347 @@
+package db
+
+// Hand-written companions to the sqlc queries. sqlc cannot generate
+// statements whose shape depends on the number of rows, so multi-row writes
+// live here and mirror the single-row queries in queries.sql.
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const upsertCountriesPrefix = `INSERT INTO countries (
+    name, capital, region, population,
+    currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at,
+    gdp_strategy, gdp_multiplier, alpha2_code, alpha3_code, numeric_code,
+    subregion, area, latitude, longitude, timezones, calling_codes, borders,
+    capital_latitude, capital_longitude
+) VALUES `
+
+const upsertCountriesSuffix = `
+ON DUPLICATE KEY UPDATE
+    capital = VALUES(capital),
+    region = VALUES(region),
+    population = VALUES(population),
+    currency_code = VALUES(currency_code),
+    exchange_rate = VALUES(exchange_rate),
+    estimated_gdp = VALUES(estimated_gdp),
+    flag_url = VALUES(flag_url),
+    last_refreshed_at = NOW(),
+    stale_since = NULL,
+    gdp_strategy = VALUES(gdp_strategy),
+    gdp_multiplier = VALUES(gdp_multiplier),
+    alpha2_code = VALUES(alpha2_code),
+    alpha3_code = VALUES(alpha3_code),
+    numeric_code = VALUES(numeric_code),
+    subregion = VALUES(subregion),
+    area = VALUES(area),
+    latitude = VALUES(latitude),
+    longitude = VALUES(longitude),
+    timezones = VALUES(timezones),
+    calling_codes = VALUES(calling_codes),
+    borders = VALUES(borders),
+    capital_latitude = VALUES(capital_latitude),
+    capital_longitude = VALUES(capital_longitude)`
+
+// UpsertCountries is the multi-row form of UpsertCountry
+func (q *Queries) UpsertCountries(ctx context.Context, rows []UpsertCountryParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(upsertCountriesPrefix)
+	args := make([]interface{}, 0, len(rows)*22)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, NOW(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			arg.Name,
+			arg.Capital,
+			arg.Region,
+			arg.Population,
+			arg.CurrencyCode,
+			arg.ExchangeRate,
+			arg.EstimatedGdp,
+			arg.FlagUrl,
+			arg.GdpStrategy,
+			arg.GdpMultiplier,
+			arg.Alpha2Code,
+			arg.Alpha3Code,
+			arg.NumericCode,
+			arg.Subregion,
+			arg.Area,
+			arg.Latitude,
+			arg.Longitude,
+			arg.Timezones,
+			arg.CallingCodes,
+			arg.Borders,
+			arg.CapitalLatitude,
+			arg.CapitalLongitude,
+		)
+	}
+	sb.WriteString(upsertCountriesSuffix)
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+const createRefreshChangesPrefix = `INSERT INTO refresh_changes (
+    job_id, country_name, change_type, field, old_value, new_value
+) VALUES `
+
+// CreateRefreshChanges is the multi-row form of CreateRefreshChange
+func (q *Queries) CreateRefreshChanges(ctx context.Context, rows []CreateRefreshChangeParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(createRefreshChangesPrefix)
+	args := make([]interface{}, 0, len(rows)*6)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			arg.JobID,
+			arg.CountryName,
+			arg.ChangeType,
+			arg.Field,
+			arg.OldValue,
+			arg.NewValue,
+		)
+	}
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+// DeleteCountriesByIDs removes every country whose id is listed
+func (q *Queries) DeleteCountriesByIDs(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := "DELETE FROM countries WHERE id IN (" + placeholders(len(ids)) + ")"
+	_, err := q.db.ExecContext(ctx, query, int64Args(ids)...)
+	return err
+}
+
+// MarkCountriesStale flags the listed countries as no longer returned
+// upstream. last_refreshed_at is assigned to itself so its ON UPDATE clause
+// does not make stale rows look freshly refreshed.
+func (q *Queries) MarkCountriesStale(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `UPDATE countries
+SET stale_since = COALESCE(stale_since, NOW()), last_refreshed_at = last_refreshed_at
+WHERE id IN (` + placeholders(len(ids)) + ")"
+	_, err := q.db.ExecContext(ctx, query, int64Args(ids)...)
+	return err
+}
+
+const upsertCurrenciesSuffix = `
+ON DUPLICATE KEY UPDATE
+    name = VALUES(name),
+    symbol = VALUES(symbol)`
+
+// UpsertCurrencies is the multi-row form of UpsertCurrency
+func (q *Queries) UpsertCurrencies(ctx context.Context, rows []UpsertCurrencyParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO currencies (code, name, symbol) VALUES ")
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?)")
+		args = append(args, arg.Code, arg.Name, arg.Symbol)
+	}
+	sb.WriteString(upsertCurrenciesSuffix)
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+// ReplaceCountryCurrencies drops the currency links of the listed countries
+// and inserts rows in their place
+func (q *Queries) ReplaceCountryCurrencies(ctx context.Context, countryIDs []int64, rows []CreateCountryCurrencyParams) error {
+	if len(countryIDs) == 0 {
+		return nil
+	}
+	query := "DELETE FROM country_currencies WHERE country_id IN (" + placeholders(len(countryIDs)) + ")"
+	if _, err := q.db.ExecContext(ctx, query, int64Args(countryIDs)...); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO country_currencies (country_id, currency_code, position) VALUES ")
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?)")
+		args = append(args, arg.CountryID, arg.CurrencyCode, arg.Position)
+	}
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+const upsertLanguagesSuffix = `
+ON DUPLICATE KEY UPDATE
+    iso639_1 = COALESCE(VALUES(iso639_1), iso639_1),
+    name = VALUES(name)`
+
+// UpsertLanguages is the multi-row form of UpsertLanguage
+func (q *Queries) UpsertLanguages(ctx context.Context, rows []UpsertLanguageParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO languages (code, iso639_1, name) VALUES ")
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?)")
+		args = append(args, arg.Code, arg.Iso6391, arg.Name)
+	}
+	sb.WriteString(upsertLanguagesSuffix)
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+// ReplaceCountryLanguages drops the language links of the listed countries
+// and inserts rows in their place
+func (q *Queries) ReplaceCountryLanguages(ctx context.Context, countryIDs []int64, rows []CreateCountryLanguageParams) error {
+	if len(countryIDs) == 0 {
+		return nil
+	}
+	query := "DELETE FROM country_languages WHERE country_id IN (" + placeholders(len(countryIDs)) + ")"
+	if _, err := q.db.ExecContext(ctx, query, int64Args(countryIDs)...); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO country_languages (country_id, language_code, position) VALUES ")
+	args := make([]interface{}, 0, len(rows)*3)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?)")
+		args = append(args, arg.CountryID, arg.LanguageCode, arg.Position)
+	}
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+// CreateExchangeRates is the multi-row form of CreateExchangeRate
+func (q *Queries) CreateExchangeRates(ctx context.Context, rows []CreateExchangeRateParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT IGNORE INTO exchange_rates (currency_code, base_code, fetched_at, rate) VALUES ")
+	args := make([]interface{}, 0, len(rows)*4)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?)")
+		args = append(args, arg.CurrencyCode, arg.BaseCode, arg.FetchedAt, arg.Rate)
+	}
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+const createCountryHistoriesPrefix = `INSERT INTO country_history (
+    country_id, country_name, capital, region, population,
+    currency_code, exchange_rate, estimated_gdp, flag_url, valid_from,
+    gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year,
+    alpha2_code, alpha3_code, numeric_code, subregion, area, latitude,
+    longitude, timezones, calling_codes, borders, capital_latitude,
+    capital_longitude, currencies, languages
+) VALUES `
+
+// CreateCountryHistories is the multi-row form of CreateCountryHistory
+func (q *Queries) CreateCountryHistories(ctx context.Context, rows []CreateCountryHistoryParams) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(createCountryHistoriesPrefix)
+	args := make([]interface{}, 0, len(rows)*28)
+	for i, arg := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			arg.CountryID,
+			arg.CountryName,
+			arg.Capital,
+			arg.Region,
+			arg.Population,
+			arg.CurrencyCode,
+			arg.ExchangeRate,
+			arg.EstimatedGdp,
+			arg.FlagUrl,
+			arg.ValidFrom,
+			arg.GdpStrategy,
+			arg.GdpMultiplier,
+			arg.ReportedGdp,
+			arg.ReportedGdpYear,
+			arg.Alpha2Code,
+			arg.Alpha3Code,
+			arg.NumericCode,
+			arg.Subregion,
+			arg.Area,
+			arg.Latitude,
+			arg.Longitude,
+			arg.Timezones,
+			arg.CallingCodes,
+			arg.Borders,
+			arg.CapitalLatitude,
+			arg.CapitalLongitude,
+			arg.Currencies,
+			arg.Languages,
+		)
+	}
+	_, err := q.db.ExecContext(ctx, sb.String(), args...)
+	return err
+}
+
+// CloseCountryHistories ends the open history version of every listed
+// country at validTo
+func (q *Queries) CloseCountryHistories(ctx context.Context, countryIDs []int64, validTo time.Time) error {
+	if len(countryIDs) == 0 {
+		return nil
+	}
+	query := "UPDATE country_history SET valid_to = ? WHERE valid_to IS NULL AND country_id IN (" + placeholders(len(countryIDs)) + ")"
+	args := append([]interface{}{validTo}, int64Args(countryIDs)...)
+	_, err := q.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// placeholders returns n comma separated "?" markers
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64Args(ids []int64) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
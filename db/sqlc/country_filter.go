@@ -0,0 +1,228 @@
+package db
+
+// CountryFilter is the hand-written query builder behind GET /countries.
+// Filters and sort orders depend on the request, which sqlc cannot express,
+// so the statement is assembled here from fixed fragments and placeholders.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sort orders understood by ListCountries; anything else sorts by id
+const (
+	SortGDPDesc         = "gdp_desc"
+	SortGDPAsc          = "gdp_asc"
+	SortReportedGDPDesc = "reported_gdp_desc"
+	SortReportedGDPAsc  = "reported_gdp_asc"
+)
+
+// CountryFilter selects, orders and limits countries. Zero values mean no
+// filter, id order and no limit.
+type CountryFilter struct {
+	Region    string
+	Subregion string
+	// Currency matches any of a country's currencies, not just the primary
+	Currency string
+	// Language matches a two- or three-letter code or an English name
+	Language       string
+	MinReportedGdp *float64
+	MaxReportedGdp *float64
+	Sort           string
+	Limit          int
+	Offset         int
+	// After continues the listing past a row, for keyset pagination
+	After *CountryKey
+}
+
+// CountryKey is the position of a row in a sorted listing: the value of the
+// sort column, unused in id order, and the id that breaks ties
+type CountryKey struct {
+	Value sql.NullString
+	ID    int64
+}
+
+// KeyOf returns the position of a country in the filter's sort order
+func (f CountryFilter) KeyOf(c Country) CountryKey {
+	key := CountryKey{ID: c.ID}
+	switch f.Sort {
+	case SortGDPDesc, SortGDPAsc:
+		key.Value = c.EstimatedGdp
+	case SortReportedGDPDesc, SortReportedGDPAsc:
+		key.Value = c.ReportedGdp
+	}
+	return key
+}
+
+// where renders the filter's conditions. Region and currency compare the
+// raw columns so idx_region and idx_currency stay usable.
+func (f CountryFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Region != "" {
+		conds = append(conds, "region = ?")
+		args = append(args, f.Region)
+	}
+	if f.Subregion != "" {
+		conds = append(conds, "subregion = ?")
+		args = append(args, f.Subregion)
+	}
+	if f.Currency != "" {
+		code := strings.ToUpper(f.Currency)
+		conds = append(conds, `(currency_code = ? OR EXISTS (
+    SELECT 1 FROM country_currencies cc
+    WHERE cc.country_id = countries.id AND cc.currency_code = ?))`)
+		args = append(args, code, code)
+	}
+	if f.Language != "" {
+		lang := strings.ToLower(strings.TrimSpace(f.Language))
+		conds = append(conds, `EXISTS (
+    SELECT 1 FROM country_languages cl
+    JOIN languages l ON l.code = cl.language_code
+    WHERE cl.country_id = countries.id
+      AND (l.code = ? OR l.iso639_1 = ? OR LOWER(l.name) = LOWER(?)))`)
+		args = append(args, lang, lang, lang)
+	}
+	if f.MinReportedGdp != nil {
+		conds = append(conds, "reported_gdp >= ?")
+		args = append(args, *f.MinReportedGdp)
+	}
+	if f.MaxReportedGdp != nil {
+		conds = append(conds, "reported_gdp <= ?")
+		args = append(args, *f.MaxReportedGdp)
+	}
+	if f.After != nil {
+		cond, after := f.after()
+		conds = append(conds, cond)
+		args = append(args, after...)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "\nWHERE " + strings.Join(conds, "\n  AND "), args
+}
+
+// after renders the keyset condition selecting the rows that sort after
+// f.After, following the NULL placement of orderBy
+func (f CountryFilter) after() (string, []interface{}) {
+	k := f.After
+	var col, cmp string
+	nullsLast := true
+	switch f.Sort {
+	case SortGDPDesc:
+		col, cmp = "estimated_gdp", "<"
+	case SortGDPAsc:
+		col, cmp, nullsLast = "estimated_gdp", ">", false
+	case SortReportedGDPDesc:
+		col, cmp = "reported_gdp", "<"
+	case SortReportedGDPAsc:
+		col, cmp = "reported_gdp", ">"
+	default:
+		return "id > ?", []interface{}{k.ID}
+	}
+
+	if !k.Value.Valid {
+		if nullsLast {
+			// the cursor is already among the trailing NULLs
+			return fmt.Sprintf("(%s IS NULL AND id > ?)", col), []interface{}{k.ID}
+		}
+		return fmt.Sprintf("((%s IS NULL AND id > ?) OR %s IS NOT NULL)", col, col), []interface{}{k.ID}
+	}
+	// compare as DECIMAL so equal values match exactly
+	cond := fmt.Sprintf("(%[1]s %[2]s CAST(? AS DECIMAL(30, 2)) OR (%[1]s = CAST(? AS DECIMAL(30, 2)) AND id > ?)", col, cmp)
+	if nullsLast {
+		cond += fmt.Sprintf(" OR %s IS NULL", col)
+	}
+	return cond + ")", []interface{}{k.Value.String, k.Value.String, k.ID}
+}
+
+// orderBy renders the sort order, always ending in id so pages are stable.
+// Estimated GDP keeps MySQL's NULL placement (last when descending, first
+// when ascending); reported GDP puts countries without a figure last
+// either way.
+func (f CountryFilter) orderBy() string {
+	switch f.Sort {
+	case SortGDPDesc:
+		return "\nORDER BY estimated_gdp DESC, id"
+	case SortGDPAsc:
+		return "\nORDER BY estimated_gdp ASC, id"
+	case SortReportedGDPDesc:
+		return "\nORDER BY reported_gdp IS NULL, reported_gdp DESC, id"
+	case SortReportedGDPAsc:
+		return "\nORDER BY reported_gdp IS NULL, reported_gdp ASC, id"
+	}
+	return "\nORDER BY id"
+}
+
+// ListCountries returns the countries matching f in its sort order
+func (q *Queries) ListCountries(ctx context.Context, f CountryFilter) ([]Country, error) {
+	where, args := f.where()
+	query := `SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at, stale_since, gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year, alpha2_code, alpha3_code, numeric_code, subregion, area, latitude, longitude, timezones, calling_codes, borders, capital_latitude, capital_longitude FROM countries` + where + f.orderBy()
+	if f.Limit > 0 {
+		query += "\nLIMIT " + strconv.Itoa(f.Limit)
+		if f.Offset > 0 {
+			query += " OFFSET " + strconv.Itoa(f.Offset)
+		}
+	}
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Country
+	for rows.Next() {
+		var i Country
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Capital,
+			&i.Region,
+			&i.Population,
+			&i.CurrencyCode,
+			&i.ExchangeRate,
+			&i.EstimatedGdp,
+			&i.FlagUrl,
+			&i.LastRefreshedAt,
+			&i.StaleSince,
+			&i.GdpStrategy,
+			&i.GdpMultiplier,
+			&i.ReportedGdp,
+			&i.ReportedGdpYear,
+			&i.Alpha2Code,
+			&i.Alpha3Code,
+			&i.NumericCode,
+			&i.Subregion,
+			&i.Area,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Timezones,
+			&i.CallingCodes,
+			&i.Borders,
+			&i.CapitalLatitude,
+			&i.CapitalLongitude,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountCountries counts the countries matching f, ignoring its sort,
+// limit and position
+func (q *Queries) CountCountries(ctx context.Context, f CountryFilter) (int64, error) {
+	f.After = nil
+	where, args := f.where()
+	var total int64
+	err := q.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM countries"+where, args...).Scan(&total)
+	return total, err
+}
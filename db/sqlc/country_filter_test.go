@@ -0,0 +1,182 @@
+package db
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// squash collapses whitespace so expected SQL can be written on one line
+func squash(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func TestCountryFilterWhere(t *testing.T) {
+	lo, hi := 1e9, 5e12
+	tests := []struct {
+		name     string
+		filter   CountryFilter
+		want     string
+		wantArgs []interface{}
+	}{
+		{name: "no filters", filter: CountryFilter{Sort: SortGDPDesc, Limit: 5}},
+		{
+			name:     "region and subregion",
+			filter:   CountryFilter{Region: "Africa", Subregion: "Western Africa"},
+			want:     "WHERE region = ? AND subregion = ?",
+			wantArgs: []interface{}{"Africa", "Western Africa"},
+		},
+		{
+			name:     "currency matches any currency",
+			filter:   CountryFilter{Currency: "xof"},
+			want:     "WHERE (currency_code = ? OR EXISTS ( SELECT 1 FROM country_currencies cc WHERE cc.country_id = countries.id AND cc.currency_code = ?))",
+			wantArgs: []interface{}{"XOF", "XOF"},
+		},
+		{
+			name:     "language by code or name",
+			filter:   CountryFilter{Language: " French "},
+			want:     "WHERE EXISTS ( SELECT 1 FROM country_languages cl JOIN languages l ON l.code = cl.language_code WHERE cl.country_id = countries.id AND (l.code = ? OR l.iso639_1 = ? OR LOWER(l.name) = LOWER(?)))",
+			wantArgs: []interface{}{"french", "french", "french"},
+		},
+		{
+			name:     "reported gdp range",
+			filter:   CountryFilter{MinReportedGdp: &lo, MaxReportedGdp: &hi},
+			want:     "WHERE reported_gdp >= ? AND reported_gdp <= ?",
+			wantArgs: []interface{}{lo, hi},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := tt.filter.where()
+			if squash(got) != tt.want {
+				t.Errorf("where = %q, want %q", squash(got), tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCountryFilterOrderBy(t *testing.T) {
+	tests := map[string]string{
+		"":                  "ORDER BY id",
+		"name":              "ORDER BY id",
+		SortGDPDesc:         "ORDER BY estimated_gdp DESC, id",
+		SortGDPAsc:          "ORDER BY estimated_gdp ASC, id",
+		SortReportedGDPDesc: "ORDER BY reported_gdp IS NULL, reported_gdp DESC, id",
+		SortReportedGDPAsc:  "ORDER BY reported_gdp IS NULL, reported_gdp ASC, id",
+	}
+	for sort, want := range tests {
+		if got := squash(CountryFilter{Sort: sort}.orderBy()); got != want {
+			t.Errorf("orderBy(%q) = %q, want %q", sort, got, want)
+		}
+	}
+}
+
+func TestCountryFilterAfter(t *testing.T) {
+	value := CountryKey{Value: sql.NullString{String: "1500.00", Valid: true}, ID: 42}
+	null := CountryKey{ID: 42}
+	const dec = "CAST(? AS DECIMAL(30, 2))"
+	tests := []struct {
+		name     string
+		sort     string
+		after    CountryKey
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "id order",
+			after:    value,
+			want:     "id > ?",
+			wantArgs: []interface{}{int64(42)},
+		},
+		{
+			name:     "descending keeps nulls last",
+			sort:     SortGDPDesc,
+			after:    value,
+			want:     "(estimated_gdp < " + dec + " OR (estimated_gdp = " + dec + " AND id > ?) OR estimated_gdp IS NULL)",
+			wantArgs: []interface{}{"1500.00", "1500.00", int64(42)},
+		},
+		{
+			name:     "descending past the last value",
+			sort:     SortGDPDesc,
+			after:    null,
+			want:     "(estimated_gdp IS NULL AND id > ?)",
+			wantArgs: []interface{}{int64(42)},
+		},
+		{
+			name:     "ascending estimate has nulls first",
+			sort:     SortGDPAsc,
+			after:    value,
+			want:     "(estimated_gdp > " + dec + " OR (estimated_gdp = " + dec + " AND id > ?))",
+			wantArgs: []interface{}{"1500.00", "1500.00", int64(42)},
+		},
+		{
+			name:     "ascending estimate still among the nulls",
+			sort:     SortGDPAsc,
+			after:    null,
+			want:     "((estimated_gdp IS NULL AND id > ?) OR estimated_gdp IS NOT NULL)",
+			wantArgs: []interface{}{int64(42)},
+		},
+		{
+			name:     "ascending reported gdp keeps nulls last",
+			sort:     SortReportedGDPAsc,
+			after:    value,
+			want:     "(reported_gdp > " + dec + " OR (reported_gdp = " + dec + " AND id > ?) OR reported_gdp IS NULL)",
+			wantArgs: []interface{}{"1500.00", "1500.00", int64(42)},
+		},
+		{
+			name:     "descending reported gdp past the last value",
+			sort:     SortReportedGDPDesc,
+			after:    null,
+			want:     "(reported_gdp IS NULL AND id > ?)",
+			wantArgs: []interface{}{int64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := CountryFilter{Sort: tt.sort, After: &tt.after}
+			got, args := f.after()
+			if got != tt.want {
+				t.Errorf("after = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCountryFilterWhereAfter(t *testing.T) {
+	f := CountryFilter{Region: "Africa", After: &CountryKey{ID: 7}}
+	got, args := f.where()
+	if want := "WHERE region = ? AND id > ?"; squash(got) != want {
+		t.Errorf("where = %q, want %q", squash(got), want)
+	}
+	if want := []interface{}{"Africa", int64(7)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestCountryFilterKeyOf(t *testing.T) {
+	c := Country{
+		ID:           3,
+		EstimatedGdp: sql.NullString{String: "10.00", Valid: true},
+		ReportedGdp:  sql.NullString{String: "20.00", Valid: true},
+	}
+	tests := map[string]sql.NullString{
+		"":                  {},
+		SortGDPDesc:         c.EstimatedGdp,
+		SortGDPAsc:          c.EstimatedGdp,
+		SortReportedGDPDesc: c.ReportedGdp,
+		SortReportedGDPAsc:  c.ReportedGdp,
+	}
+	for sort, want := range tests {
+		key := CountryFilter{Sort: sort}.KeyOf(c)
+		if key.ID != 3 || key.Value != want {
+			t.Errorf("KeyOf with sort %q = %+v, want value %+v", sort, key, want)
+		}
+	}
+}
@@ -6,17 +6,150 @@ package db
 
 import (
 	"database/sql"
+	"time"
 )
 
 type Country struct {
-	ID              int64          `json:"id"`
-	Name            string         `json:"name"`
-	Capital         sql.NullString `json:"capital"`
-	Region          sql.NullString `json:"region"`
-	Population      int64          `json:"population"`
-	CurrencyCode    sql.NullString `json:"currency_code"`
-	ExchangeRate    sql.NullString `json:"exchange_rate"`
-	EstimatedGdp    sql.NullString `json:"estimated_gdp"`
-	FlagUrl         sql.NullString `json:"flag_url"`
-	LastRefreshedAt sql.NullTime   `json:"last_refreshed_at"`
+	ID               int64          `json:"id"`
+	Name             string         `json:"name"`
+	Capital          sql.NullString `json:"capital"`
+	Region           sql.NullString `json:"region"`
+	Population       int64          `json:"population"`
+	CurrencyCode     sql.NullString `json:"currency_code"`
+	ExchangeRate     sql.NullString `json:"exchange_rate"`
+	EstimatedGdp     sql.NullString `json:"estimated_gdp"`
+	FlagUrl          sql.NullString `json:"flag_url"`
+	LastRefreshedAt  sql.NullTime   `json:"last_refreshed_at"`
+	StaleSince       sql.NullTime   `json:"stale_since"`
+	GdpStrategy      sql.NullString `json:"gdp_strategy"`
+	GdpMultiplier    sql.NullString `json:"gdp_multiplier"`
+	ReportedGdp      sql.NullString `json:"reported_gdp"`
+	ReportedGdpYear  sql.NullInt32  `json:"reported_gdp_year"`
+	Alpha2Code       sql.NullString `json:"alpha2_code"`
+	Alpha3Code       sql.NullString `json:"alpha3_code"`
+	NumericCode      sql.NullString `json:"numeric_code"`
+	Subregion        sql.NullString `json:"subregion"`
+	Area             sql.NullString `json:"area"`
+	Latitude         sql.NullString `json:"latitude"`
+	Longitude        sql.NullString `json:"longitude"`
+	Timezones        sql.NullString `json:"timezones"`
+	CallingCodes     sql.NullString `json:"calling_codes"`
+	Borders          sql.NullString `json:"borders"`
+	CapitalLatitude  sql.NullString `json:"capital_latitude"`
+	CapitalLongitude sql.NullString `json:"capital_longitude"`
+}
+
+type CountryHistory struct {
+	ID               int64          `json:"id"`
+	CountryID        sql.NullInt64  `json:"country_id"`
+	CountryName      string         `json:"country_name"`
+	Capital          sql.NullString `json:"capital"`
+	Region           sql.NullString `json:"region"`
+	Population       int64          `json:"population"`
+	CurrencyCode     sql.NullString `json:"currency_code"`
+	ExchangeRate     sql.NullString `json:"exchange_rate"`
+	EstimatedGdp     sql.NullString `json:"estimated_gdp"`
+	FlagUrl          sql.NullString `json:"flag_url"`
+	ValidFrom        time.Time      `json:"valid_from"`
+	ValidTo          sql.NullTime   `json:"valid_to"`
+	GdpStrategy      sql.NullString `json:"gdp_strategy"`
+	GdpMultiplier    sql.NullString `json:"gdp_multiplier"`
+	ReportedGdp      sql.NullString `json:"reported_gdp"`
+	ReportedGdpYear  sql.NullInt32  `json:"reported_gdp_year"`
+	Alpha2Code       sql.NullString `json:"alpha2_code"`
+	Alpha3Code       sql.NullString `json:"alpha3_code"`
+	NumericCode      sql.NullString `json:"numeric_code"`
+	Subregion        sql.NullString `json:"subregion"`
+	Area             sql.NullString `json:"area"`
+	Latitude         sql.NullString `json:"latitude"`
+	Longitude        sql.NullString `json:"longitude"`
+	Timezones        sql.NullString `json:"timezones"`
+	CallingCodes     sql.NullString `json:"calling_codes"`
+	Borders          sql.NullString `json:"borders"`
+	CapitalLatitude  sql.NullString `json:"capital_latitude"`
+	CapitalLongitude sql.NullString `json:"capital_longitude"`
+	Currencies       sql.NullString `json:"currencies"`
+	Languages        sql.NullString `json:"languages"`
+}
+
+type CountryCurrency struct {
+	CountryID    int64  `json:"country_id"`
+	CurrencyCode string `json:"currency_code"`
+	Position     int32  `json:"position"`
+}
+
+type CountryLanguage struct {
+	CountryID    int64  `json:"country_id"`
+	LanguageCode string `json:"language_code"`
+	Position     int32  `json:"position"`
+}
+
+type Currency struct {
+	Code   string         `json:"code"`
+	Name   sql.NullString `json:"name"`
+	Symbol sql.NullString `json:"symbol"`
+}
+
+type ExchangeRate struct {
+	CurrencyCode string    `json:"currency_code"`
+	BaseCode     string    `json:"base_code"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	Rate         string    `json:"rate"`
+}
+
+type Flag struct {
+	UrlHash      string         `json:"url_hash"`
+	Url          string         `json:"url"`
+	ContentHash  string         `json:"content_hash"`
+	ContentType  string         `json:"content_type"`
+	Size         int64          `json:"size"`
+	Etag         sql.NullString `json:"etag"`
+	LastModified sql.NullString `json:"last_modified"`
+	FetchedAt    time.Time      `json:"fetched_at"`
+	CheckedAt    time.Time      `json:"checked_at"`
+}
+
+type Language struct {
+	Code    string         `json:"code"`
+	Iso6391 sql.NullString `json:"iso639_1"`
+	Name    sql.NullString `json:"name"`
+}
+
+type QuarantinedCountry struct {
+	ID          int64         `json:"id"`
+	JobID       sql.NullInt64 `json:"job_id"`
+	CountryName string        `json:"country_name"`
+	Reason      string        `json:"reason"`
+	Payload     string        `json:"payload"`
+	Status      string        `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	AcceptedAt  sql.NullTime  `json:"accepted_at"`
+}
+
+type RefreshChange struct {
+	ID          int64          `json:"id"`
+	JobID       int64          `json:"job_id"`
+	CountryName string         `json:"country_name"`
+	ChangeType  string         `json:"change_type"`
+	Field       sql.NullString `json:"field"`
+	OldValue    sql.NullString `json:"old_value"`
+	NewValue    sql.NullString `json:"new_value"`
+}
+
+type RefreshJob struct {
+	ID                   int64          `json:"id"`
+	Status               string         `json:"status"`
+	CountriesProcessed   int32          `json:"countries_processed"`
+	CountriesFailed      int32          `json:"countries_failed"`
+	ErrorMessage         sql.NullString `json:"error_message"`
+	CreatedAt            time.Time      `json:"created_at"`
+	StartedAt            sql.NullTime   `json:"started_at"`
+	FinishedAt           sql.NullTime   `json:"finished_at"`
+	Source               string         `json:"source"`
+	CountriesAdded       int32          `json:"countries_added"`
+	CountriesRemoved     int32          `json:"countries_removed"`
+	CountriesModified    int32          `json:"countries_modified"`
+	Scope                sql.NullString `json:"scope"`
+	CountriesQuarantined int32          `json:"countries_quarantined"`
+	RateProvider         sql.NullString `json:"rate_provider"`
 }
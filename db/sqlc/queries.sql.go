@@ -20,7 +20,7 @@ func (q *Queries) DeleteCountryByName(ctx context.Context, lower string) error {
 }
 
 const getAllCountries = `-- name: GetAllCountries :many
-SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at FROM countries
+SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at, stale_since, gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year, alpha2_code, alpha3_code, numeric_code, subregion, area, latitude, longitude, timezones, calling_codes, borders, capital_latitude, capital_longitude FROM countries
 ORDER BY id
 `
 
@@ -44,6 +44,23 @@ func (q *Queries) GetAllCountries(ctx context.Context) ([]Country, error) {
 			&i.EstimatedGdp,
 			&i.FlagUrl,
 			&i.LastRefreshedAt,
+			&i.StaleSince,
+			&i.GdpStrategy,
+			&i.GdpMultiplier,
+			&i.ReportedGdp,
+			&i.ReportedGdpYear,
+			&i.Alpha2Code,
+			&i.Alpha3Code,
+			&i.NumericCode,
+			&i.Subregion,
+			&i.Area,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Timezones,
+			&i.CallingCodes,
+			&i.Borders,
+			&i.CapitalLatitude,
+			&i.CapitalLongitude,
 		); err != nil {
 			return nil, err
 		}
@@ -58,8 +75,49 @@ func (q *Queries) GetAllCountries(ctx context.Context) ([]Country, error) {
 	return items, nil
 }
 
+const getCountryByCode = `-- name: GetCountryByCode :one
+SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at, stale_since, gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year, alpha2_code, alpha3_code, numeric_code, subregion, area, latitude, longitude, timezones, calling_codes, borders, capital_latitude, capital_longitude FROM countries
+WHERE alpha2_code = ? OR alpha3_code = ? OR numeric_code = ?
+LIMIT 1
+`
+
+func (q *Queries) GetCountryByCode(ctx context.Context, code sql.NullString) (Country, error) {
+	row := q.db.QueryRowContext(ctx, getCountryByCode, code, code, code)
+	var i Country
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Capital,
+		&i.Region,
+		&i.Population,
+		&i.CurrencyCode,
+		&i.ExchangeRate,
+		&i.EstimatedGdp,
+		&i.FlagUrl,
+		&i.LastRefreshedAt,
+		&i.StaleSince,
+		&i.GdpStrategy,
+		&i.GdpMultiplier,
+		&i.ReportedGdp,
+		&i.ReportedGdpYear,
+		&i.Alpha2Code,
+		&i.Alpha3Code,
+		&i.NumericCode,
+		&i.Subregion,
+		&i.Area,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Timezones,
+		&i.CallingCodes,
+		&i.Borders,
+		&i.CapitalLatitude,
+		&i.CapitalLongitude,
+	)
+	return i, err
+}
+
 const getCountryByName = `-- name: GetCountryByName :one
-SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at FROM countries
+SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at, stale_since, gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year, alpha2_code, alpha3_code, numeric_code, subregion, area, latitude, longitude, timezones, calling_codes, borders, capital_latitude, capital_longitude FROM countries
 WHERE LOWER(name) = LOWER(?)
 `
 
@@ -77,6 +135,23 @@ func (q *Queries) GetCountryByName(ctx context.Context, lower string) (Country,
 		&i.EstimatedGdp,
 		&i.FlagUrl,
 		&i.LastRefreshedAt,
+		&i.StaleSince,
+		&i.GdpStrategy,
+		&i.GdpMultiplier,
+		&i.ReportedGdp,
+		&i.ReportedGdpYear,
+		&i.Alpha2Code,
+		&i.Alpha3Code,
+		&i.NumericCode,
+		&i.Subregion,
+		&i.Area,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Timezones,
+		&i.CallingCodes,
+		&i.Borders,
+		&i.CapitalLatitude,
+		&i.CapitalLongitude,
 	)
 	return i, err
 }
@@ -95,7 +170,7 @@ func (q *Queries) GetLatestRefreshTime(ctx context.Context) (sql.NullTime, error
 }
 
 const getTopCountriesByGDP = `-- name: GetTopCountriesByGDP :many
-SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at FROM countries
+SELECT id, name, capital, region, population, currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at, stale_since, gdp_strategy, gdp_multiplier, reported_gdp, reported_gdp_year, alpha2_code, alpha3_code, numeric_code, subregion, area, latitude, longitude, timezones, calling_codes, borders, capital_latitude, capital_longitude FROM countries
 WHERE estimated_gdp IS NOT NULL
 ORDER BY estimated_gdp DESC
 LIMIT ?
@@ -121,6 +196,23 @@ func (q *Queries) GetTopCountriesByGDP(ctx context.Context, limit int32) ([]Coun
 			&i.EstimatedGdp,
 			&i.FlagUrl,
 			&i.LastRefreshedAt,
+			&i.StaleSince,
+			&i.GdpStrategy,
+			&i.GdpMultiplier,
+			&i.ReportedGdp,
+			&i.ReportedGdpYear,
+			&i.Alpha2Code,
+			&i.Alpha3Code,
+			&i.NumericCode,
+			&i.Subregion,
+			&i.Area,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Timezones,
+			&i.CallingCodes,
+			&i.Borders,
+			&i.CapitalLatitude,
+			&i.CapitalLongitude,
 		); err != nil {
 			return nil, err
 		}
@@ -146,11 +238,47 @@ func (q *Queries) GetTotalCount(ctx context.Context) (int64, error) {
 	return total, err
 }
 
+const renameCountry = `-- name: RenameCountry :exec
+UPDATE countries
+SET name = ?
+WHERE id = ?
+`
+
+type RenameCountryParams struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) RenameCountry(ctx context.Context, arg RenameCountryParams) error {
+	_, err := q.db.ExecContext(ctx, renameCountry, arg.Name, arg.ID)
+	return err
+}
+
+const updateReportedGDP = `-- name: UpdateReportedGDP :exec
+UPDATE countries
+SET reported_gdp = ?, reported_gdp_year = ?, last_refreshed_at = last_refreshed_at
+WHERE id = ?
+`
+
+type UpdateReportedGDPParams struct {
+	ReportedGdp     sql.NullString `json:"reported_gdp"`
+	ReportedGdpYear sql.NullInt32  `json:"reported_gdp_year"`
+	ID              int64          `json:"id"`
+}
+
+func (q *Queries) UpdateReportedGDP(ctx context.Context, arg UpdateReportedGDPParams) error {
+	_, err := q.db.ExecContext(ctx, updateReportedGDP, arg.ReportedGdp, arg.ReportedGdpYear, arg.ID)
+	return err
+}
+
 const upsertCountry = `-- name: UpsertCountry :exec
 INSERT INTO countries (
     name, capital, region, population, 
-    currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())
+    currency_code, exchange_rate, estimated_gdp, flag_url, last_refreshed_at,
+    gdp_strategy, gdp_multiplier, alpha2_code, alpha3_code, numeric_code,
+    subregion, area, latitude, longitude, timezones, calling_codes, borders,
+    capital_latitude, capital_longitude
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON DUPLICATE KEY UPDATE
     capital = VALUES(capital),
     region = VALUES(region),
@@ -159,18 +287,47 @@ ON DUPLICATE KEY UPDATE
     exchange_rate = VALUES(exchange_rate),
     estimated_gdp = VALUES(estimated_gdp),
     flag_url = VALUES(flag_url),
-    last_refreshed_at = NOW()
+    last_refreshed_at = NOW(),
+    stale_since = NULL,
+    gdp_strategy = VALUES(gdp_strategy),
+    gdp_multiplier = VALUES(gdp_multiplier),
+    alpha2_code = VALUES(alpha2_code),
+    alpha3_code = VALUES(alpha3_code),
+    numeric_code = VALUES(numeric_code),
+    subregion = VALUES(subregion),
+    area = VALUES(area),
+    latitude = VALUES(latitude),
+    longitude = VALUES(longitude),
+    timezones = VALUES(timezones),
+    calling_codes = VALUES(calling_codes),
+    borders = VALUES(borders),
+    capital_latitude = VALUES(capital_latitude),
+    capital_longitude = VALUES(capital_longitude)
 `
 
 type UpsertCountryParams struct {
-	Name         string         `json:"name"`
-	Capital      sql.NullString `json:"capital"`
-	Region       sql.NullString `json:"region"`
-	Population   int64          `json:"population"`
-	CurrencyCode sql.NullString `json:"currency_code"`
-	ExchangeRate sql.NullString `json:"exchange_rate"`
-	EstimatedGdp sql.NullString `json:"estimated_gdp"`
-	FlagUrl      sql.NullString `json:"flag_url"`
+	Name             string         `json:"name"`
+	Capital          sql.NullString `json:"capital"`
+	Region           sql.NullString `json:"region"`
+	Population       int64          `json:"population"`
+	CurrencyCode     sql.NullString `json:"currency_code"`
+	ExchangeRate     sql.NullString `json:"exchange_rate"`
+	EstimatedGdp     sql.NullString `json:"estimated_gdp"`
+	FlagUrl          sql.NullString `json:"flag_url"`
+	GdpStrategy      sql.NullString `json:"gdp_strategy"`
+	GdpMultiplier    sql.NullString `json:"gdp_multiplier"`
+	Alpha2Code       sql.NullString `json:"alpha2_code"`
+	Alpha3Code       sql.NullString `json:"alpha3_code"`
+	NumericCode      sql.NullString `json:"numeric_code"`
+	Subregion        sql.NullString `json:"subregion"`
+	Area             sql.NullString `json:"area"`
+	Latitude         sql.NullString `json:"latitude"`
+	Longitude        sql.NullString `json:"longitude"`
+	Timezones        sql.NullString `json:"timezones"`
+	CallingCodes     sql.NullString `json:"calling_codes"`
+	Borders          sql.NullString `json:"borders"`
+	CapitalLatitude  sql.NullString `json:"capital_latitude"`
+	CapitalLongitude sql.NullString `json:"capital_longitude"`
 }
 
 func (q *Queries) UpsertCountry(ctx context.Context, arg UpsertCountryParams) error {
@@ -183,6 +340,20 @@ func (q *Queries) UpsertCountry(ctx context.Context, arg UpsertCountryParams) er
 		arg.ExchangeRate,
 		arg.EstimatedGdp,
 		arg.FlagUrl,
+		arg.GdpStrategy,
+		arg.GdpMultiplier,
+		arg.Alpha2Code,
+		arg.Alpha3Code,
+		arg.NumericCode,
+		arg.Subregion,
+		arg.Area,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Timezones,
+		arg.CallingCodes,
+		arg.Borders,
+		arg.CapitalLatitude,
+		arg.CapitalLongitude,
 	)
 	return err
 }
@@ -3,20 +3,50 @@ package database
 import (
 	"database/sql"
 	_ "embed"
+	"errors"
 	"log"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 //go:embed schema.sql
 var schemaSQL string
 
+// MySQL error numbers for re-adding a column or index that already exists.
+// MySQL has no ADD COLUMN IF NOT EXISTS, so schema.sql relies on these being
+// ignored to make its ALTER TABLE statements safe to re-run.
+const (
+	errDupFieldName = 1060
+	errDupKeyName   = 1061
+)
+
+// RunMigrations applies schema.sql one statement at a time, since the
+// MySQL driver rejects multi-statement Exec calls by default.
 func RunMigrations(db *sql.DB) error {
 	log.Println("Running database migrations...")
 
-	_, err := db.Exec(schemaSQL)
-	if err != nil {
-		return err
+	for _, stmt := range strings.Split(schemaSQL, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			if isAlreadyApplied(err) {
+				continue
+			}
+			return err
+		}
 	}
 
 	log.Println("✓ Migrations completed successfully")
 	return nil
 }
+
+func isAlreadyApplied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == errDupFieldName || mysqlErr.Number == errDupKeyName
+}
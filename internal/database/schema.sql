@@ -12,4 +12,220 @@ CREATE TABLE IF NOT EXISTS countries (
     
     INDEX idx_region (region),
     INDEX idx_currency (currency_code)
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS refresh_jobs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    countries_processed INT NOT NULL DEFAULT 0,
+    countries_failed INT NOT NULL DEFAULT 0,
+    error_message TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+
+    INDEX idx_status (status)
+);
+
+ALTER TABLE refresh_jobs ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'live';
+
+ALTER TABLE refresh_jobs ADD COLUMN countries_added INT NOT NULL DEFAULT 0;
+
+ALTER TABLE refresh_jobs ADD COLUMN countries_removed INT NOT NULL DEFAULT 0;
+
+ALTER TABLE refresh_jobs ADD COLUMN countries_modified INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS refresh_changes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NOT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    field VARCHAR(50) NULL,
+    old_value TEXT NULL,
+    new_value TEXT NULL,
+
+    INDEX idx_job (job_id)
+);
+
+ALTER TABLE countries ADD COLUMN stale_since TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(255) NULL,
+    symbol VARCHAR(50) NULL
+);
+
+CREATE TABLE IF NOT EXISTS country_currencies (
+    country_id BIGINT NOT NULL,
+    currency_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, currency_code),
+    INDEX idx_currency_code (currency_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency_code VARCHAR(10) NOT NULL,
+    base_code VARCHAR(10) NOT NULL,
+    fetched_at TIMESTAMP NOT NULL,
+    rate DECIMAL(20, 6) NOT NULL,
+
+    PRIMARY KEY (currency_code, base_code, fetched_at),
+    INDEX idx_fetched_at (fetched_at)
+);
+
+CREATE TABLE IF NOT EXISTS country_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    country_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    capital VARCHAR(255) NULL,
+    region VARCHAR(100) NULL,
+    population BIGINT NOT NULL,
+    currency_code VARCHAR(10) NULL,
+    exchange_rate DECIMAL(20, 6) NULL,
+    estimated_gdp DECIMAL(30, 2) NULL,
+    flag_url TEXT NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_to TIMESTAMP NULL,
+
+    INDEX idx_name_valid (country_name, valid_from),
+    INDEX idx_valid (valid_from, valid_to)
+);
+
+INSERT INTO country_history (
+    country_id, country_name, capital, region, population,
+    currency_code, exchange_rate, estimated_gdp, flag_url, valid_from
+)
+SELECT c.id, c.name, c.capital, c.region, c.population,
+    c.currency_code, c.exchange_rate, c.estimated_gdp, c.flag_url,
+    COALESCE(c.last_refreshed_at, NOW())
+FROM countries c
+WHERE NOT EXISTS (
+    SELECT 1 FROM country_history h
+    WHERE h.country_name = c.name AND h.valid_to IS NULL
+);
+
+ALTER TABLE countries ADD COLUMN gdp_strategy VARCHAR(20) NULL;
+ALTER TABLE countries ADD COLUMN gdp_multiplier DECIMAL(12, 4) NULL;
+
+ALTER TABLE countries ADD COLUMN reported_gdp DECIMAL(30, 2) NULL;
+ALTER TABLE countries ADD COLUMN reported_gdp_year INT NULL;
+
+ALTER TABLE refresh_jobs ADD COLUMN scope VARCHAR(255) NULL;
+
+ALTER TABLE refresh_jobs ADD COLUMN countries_quarantined INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS quarantined_countries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_id BIGINT NULL,
+    country_name VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP NULL,
+
+    INDEX idx_status (status),
+    INDEX idx_country_name (country_name)
+);
+
+ALTER TABLE refresh_jobs ADD COLUMN rate_provider VARCHAR(50) NULL;
+
+ALTER TABLE countries ADD COLUMN alpha2_code VARCHAR(2) NULL;
+ALTER TABLE countries ADD COLUMN alpha3_code VARCHAR(3) NULL;
+ALTER TABLE countries ADD COLUMN numeric_code VARCHAR(3) NULL;
+ALTER TABLE countries ADD UNIQUE INDEX idx_alpha2_code (alpha2_code);
+ALTER TABLE countries ADD UNIQUE INDEX idx_alpha3_code (alpha3_code);
+ALTER TABLE countries ADD UNIQUE INDEX idx_numeric_code (numeric_code);
+
+ALTER TABLE countries ADD COLUMN subregion VARCHAR(100) NULL;
+ALTER TABLE countries ADD COLUMN area DECIMAL(15, 2) NULL;
+ALTER TABLE countries ADD COLUMN latitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN longitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN timezones TEXT NULL;
+ALTER TABLE countries ADD COLUMN calling_codes TEXT NULL;
+ALTER TABLE countries ADD COLUMN borders TEXT NULL;
+ALTER TABLE countries ADD INDEX idx_subregion (subregion);
+
+CREATE TABLE IF NOT EXISTS languages (
+    code VARCHAR(10) PRIMARY KEY,
+    iso639_1 VARCHAR(2) NULL,
+    name VARCHAR(255) NULL,
+
+    INDEX idx_iso639_1 (iso639_1)
+);
+
+CREATE TABLE IF NOT EXISTS country_languages (
+    country_id BIGINT NOT NULL,
+    language_code VARCHAR(10) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (country_id, language_code),
+    INDEX idx_language_code (language_code),
+    FOREIGN KEY (country_id) REFERENCES countries(id) ON DELETE CASCADE
+);
+
+ALTER TABLE countries ADD COLUMN capital_latitude DECIMAL(9, 6) NULL;
+ALTER TABLE countries ADD COLUMN capital_longitude DECIMAL(9, 6) NULL;
+
+CREATE TABLE IF NOT EXISTS flags (
+    url_hash CHAR(64) PRIMARY KEY,
+    url TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    etag VARCHAR(255) NULL,
+    last_modified VARCHAR(64) NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE country_history ADD COLUMN gdp_strategy VARCHAR(20) NULL;
+ALTER TABLE country_history ADD COLUMN gdp_multiplier DECIMAL(12, 4) NULL;
+ALTER TABLE country_history ADD COLUMN reported_gdp DECIMAL(30, 2) NULL;
+ALTER TABLE country_history ADD COLUMN reported_gdp_year INT NULL;
+ALTER TABLE country_history ADD COLUMN alpha2_code VARCHAR(2) NULL;
+ALTER TABLE country_history ADD COLUMN alpha3_code VARCHAR(3) NULL;
+ALTER TABLE country_history ADD COLUMN numeric_code VARCHAR(3) NULL;
+ALTER TABLE country_history ADD COLUMN subregion VARCHAR(100) NULL;
+ALTER TABLE country_history ADD COLUMN area DECIMAL(15, 2) NULL;
+ALTER TABLE country_history ADD COLUMN latitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN longitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN timezones TEXT NULL;
+ALTER TABLE country_history ADD COLUMN calling_codes TEXT NULL;
+ALTER TABLE country_history ADD COLUMN borders TEXT NULL;
+ALTER TABLE country_history ADD COLUMN capital_latitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN capital_longitude DECIMAL(9, 6) NULL;
+ALTER TABLE country_history ADD COLUMN currencies TEXT NULL;
+ALTER TABLE country_history ADD COLUMN languages TEXT NULL;
+ALTER TABLE country_history ADD INDEX idx_country_valid (country_id, valid_from);
+
+-- open versions take the columns they lacked from the current row
+UPDATE country_history h
+JOIN countries c ON c.id = h.country_id
+SET h.gdp_strategy = c.gdp_strategy,
+    h.gdp_multiplier = c.gdp_multiplier,
+    h.reported_gdp = c.reported_gdp,
+    h.reported_gdp_year = c.reported_gdp_year,
+    h.alpha2_code = c.alpha2_code,
+    h.alpha3_code = c.alpha3_code,
+    h.numeric_code = c.numeric_code,
+    h.subregion = c.subregion,
+    h.area = c.area,
+    h.latitude = c.latitude,
+    h.longitude = c.longitude,
+    h.timezones = c.timezones,
+    h.calling_codes = c.calling_codes,
+    h.borders = c.borders,
+    h.capital_latitude = c.capital_latitude,
+    h.capital_longitude = c.capital_longitude,
+    h.currencies = (
+        SELECT CONCAT('["', GROUP_CONCAT(currency_code ORDER BY position SEPARATOR '","'), '"]')
+        FROM country_currencies WHERE country_id = c.id
+    ),
+    h.languages = (
+        SELECT CONCAT('["', GROUP_CONCAT(language_code ORDER BY position SEPARATOR '","'), '"]')
+        FROM country_languages WHERE country_id = c.id
+    )
+WHERE h.valid_to IS NULL AND h.alpha3_code IS NULL AND h.subregion IS NULL;
@@ -2,10 +2,16 @@ package internal
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
-	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	db "github.com/franzego/stage02/db/sqlc"
 	internal "github.com/franzego/stage02/internal/services"
@@ -13,74 +19,264 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// timeLayout is the format used for every timestamp in API responses
+const timeLayout = "2006-01-02T15:04:05Z"
+
+// dateLayout is the bare date accepted by time query parameters
+const dateLayout = "2006-01-02"
+
+// maxGDPUploadBytes bounds a GDP CSV posted as the request body
+const maxGDPUploadBytes = 16 << 20
+
+// defaultNearestLimit and maxNearestLimit bound GET /countries/nearest
+const (
+	defaultNearestLimit = 10
+	maxNearestLimit     = 250
+)
+
 type CountryHandler struct {
-	service *internal.CountryService
-	queries *db.Queries
+	service   *internal.CountryService
+	jobs      *internal.RefreshJobService
+	scheduler *internal.RefreshScheduler
+	queries   *db.Queries
 }
 
-func NewCountryHandler(queries *db.Queries) *CountryHandler {
+// NewCountryHandler wires the HTTP handlers. scheduler may be nil when
+// scheduled refreshes are disabled.
+func NewCountryHandler(queries *db.Queries, service *internal.CountryService, jobs *internal.RefreshJobService, scheduler *internal.RefreshScheduler) *CountryHandler {
 	return &CountryHandler{
-		service: internal.NewCountryService(queries),
-		queries: queries,
+		service:   service,
+		jobs:      jobs,
+		scheduler: scheduler,
+		queries:   queries,
 	}
 }
 
 // POST /countries/refresh
 func (h *CountryHandler) RefreshCountries(c *gin.Context) {
-	if err := h.service.RefreshCountries(); err != nil {
-		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
-			Error:   "External data source unavailable",
+	h.enqueueRefresh(c, internal.RefreshOptions{
+		Source:    c.Query("source"),
+		Reconcile: c.Query("reconcile"),
+		Region:    c.Query("region"),
+	})
+}
+
+// POST /countries/:name/refresh
+func (h *CountryHandler) RefreshCountry(c *gin.Context) {
+	// upstream searches by name, so a stored country's code is swapped for it
+	name, err := h.service.CountryName(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
 			Details: err.Error(),
 		})
 		return
 	}
+	h.enqueueRefresh(c, internal.RefreshOptions{
+		Source: c.Query("source"),
+		Name:   name,
+	})
+}
 
-	c.JSON(http.StatusOK, models.MessageResponse{
-		Message: "Countries refreshed successfully",
+// enqueueRefresh queues a refresh job and answers 202 with its status URL
+func (h *CountryHandler) enqueueRefresh(c *gin.Context, opts internal.RefreshOptions) {
+	job, err := h.jobs.Enqueue(c.Request.Context(), opts)
+	var inProgress *internal.RefreshInProgressError
+	if errors.As(err, &inProgress) {
+		response := models.RefreshConflictResponse{
+			Error:   "Refresh already in progress",
+			Details: err.Error(),
+		}
+		if inProgress.Job.ID != 0 {
+			job := mapJobToResponse(inProgress.Job)
+			response.Job = &job
+			statusURL := fmt.Sprintf("/refresh/jobs/%d", inProgress.Job.ID)
+			c.Header("Location", statusURL)
+		}
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, internal.ErrUnknownSource) || errors.Is(err, internal.ErrUnknownReconcile) ||
+			errors.Is(err, internal.ErrInvalidScope) {
+			status = http.StatusBadRequest
+		} else if err == internal.ErrQueueFull {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Could not start refresh",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	statusURL := fmt.Sprintf("/refresh/jobs/%d", job.ID)
+	c.Header("Location", statusURL)
+	c.JSON(http.StatusAccepted, models.RefreshAcceptedResponse{
+		Message:   "Refresh queued",
+		JobID:     job.ID,
+		StatusURL: statusURL,
 	})
 }
 
-// Get /countries
-func (h *CountryHandler) GetAllCountries(c *gin.Context) {
-	countries, err := h.service.GetAllCountries()
+// GET /refresh/jobs/:id
+func (h *CountryHandler) GetRefreshJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid job id",
+			Details: err.Error(),
+		})
+		return
+	}
+	job, err := h.jobs.GetJob(c.Request.Context(), id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Refresh job not found",
+				Details: err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "External data source not available",
+			Error:   "Internal Server Error",
 			Details: err.Error(),
 		})
 		return
 	}
 
-	// filter by region
-	region := c.Query("region")
-	currency := c.Query("currency")
-	sortBy := c.Query("sort")
-	if region != "" {
-		filtered := []db.Country{}
-		for _, country := range countries {
-			if country.Region.Valid && strings.EqualFold(country.Region.String, region) {
-				filtered = append(filtered, country)
-			}
+	c.JSON(http.StatusOK, mapJobToResponse(job))
+}
+
+// GET /refresh/jobs/:id/changes
+func (h *CountryHandler) GetRefreshJobChanges(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid job id",
+			Details: err.Error(),
+		})
+		return
+	}
+	job, err := h.jobs.GetJob(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Refresh job not found",
+				Details: err.Error(),
+			})
+			return
 		}
-		countries = filtered
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
 	}
-	if currency != "" {
-		filtered := []db.Country{}
-		for _, country := range countries {
-			if country.CurrencyCode.Valid && strings.EqualFold(country.CurrencyCode.String, currency) {
-				filtered = append(filtered, country)
+	changes, err := h.jobs.GetChanges(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// filter by change type
+	changeType := c.Query("type")
+	response := models.RefreshChangesResponse{
+		JobID: job.ID,
+		Summary: models.ChangeSummary{
+			Added:    job.CountriesAdded,
+			Removed:  job.CountriesRemoved,
+			Modified: job.CountriesModified,
+		},
+		Changes: []models.CountryChangeResponse{},
+	}
+	// rows arrive grouped by country, one per changed field
+	for _, ch := range changes {
+		if changeType != "" && !strings.EqualFold(ch.ChangeType, changeType) {
+			continue
+		}
+		n := len(response.Changes)
+		if n == 0 || response.Changes[n-1].Country != ch.CountryName || response.Changes[n-1].ChangeType != ch.ChangeType {
+			response.Changes = append(response.Changes, models.CountryChangeResponse{
+				Country:    ch.CountryName,
+				ChangeType: ch.ChangeType,
+			})
+			n++
+		}
+		if ch.Field.Valid {
+			field := models.FieldChangeResponse{Field: ch.Field.String}
+			if ch.OldValue.Valid {
+				field.OldValue = &ch.OldValue.String
 			}
+			if ch.NewValue.Valid {
+				field.NewValue = &ch.NewValue.String
+			}
+			response.Changes[n-1].Fields = append(response.Changes[n-1].Fields, field)
 		}
-		countries = filtered
 	}
-	if sortBy == "gdp_desc" {
-		sort.Slice(countries, func(i, j int) bool {
-			return ParseNullStringFloat(countries[i].EstimatedGdp) > ParseNullStringFloat(countries[j].EstimatedGdp)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Get /countries
+func (h *CountryHandler) GetAllCountries(c *gin.Context) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseCountryFilter(c)
+	if !ok {
+		return
+	}
+	cursor := c.Query("cursor")
+	if cursor != "" && !asOf.IsZero() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid cursor",
+			Details: "cursor cannot be combined with as_of",
 		})
-	} else if sortBy == "gdp_asc" {
-		sort.Slice(countries, func(i, j int) bool {
-			return ParseNullStringFloat(countries[i].EstimatedGdp) < ParseNullStringFloat(countries[j].EstimatedGdp)
+		return
+	}
+	if cursor != "" && filter.Offset > 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid cursor",
+			Details: "cursor cannot be combined with offset",
 		})
+		return
+	}
+	var countries []db.Country
+	var err error
+	if asOf.IsZero() && (cursor != "" || filter.Limit > 0) {
+		var page internal.CountryPage
+		page, err = h.service.ListCountriesPage(filter, cursor)
+		if errors.Is(err, internal.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid cursor",
+				Details: err.Error(),
+			})
+			return
+		}
+		if err == nil {
+			countries = page.Countries
+			c.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+			if page.Next != "" {
+				c.Header("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextPageURL(c, page.Next)))
+			}
+		}
+	} else if asOf.IsZero() {
+		countries, err = h.service.ListCountries(filter)
+	} else {
+		countries, err = h.service.ListCountriesAsOf(asOf, filter)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "External data source not available",
+			Details: err.Error(),
+		})
+		return
 	}
 
 	// Map DB models to response models
@@ -91,9 +287,98 @@ func (h *CountryHandler) GetAllCountries(c *gin.Context) {
 	c.JSON(http.StatusOK, responses)
 }
 
+// nextPageURL is the request URL with its cursor replaced by next and any
+// offset dropped, since the cursor already carries the position
+func nextPageURL(c *gin.Context, next string) string {
+	query := c.Request.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", next)
+	u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// parseCountryFilter reads the filter, sort and limit query parameters of
+// GET /countries, answering 400 itself when one is malformed
+func parseCountryFilter(c *gin.Context) (db.CountryFilter, bool) {
+	filter := db.CountryFilter{
+		Region:    c.Query("region"),
+		Subregion: c.Query("subregion"),
+		Currency:  c.Query("currency"),
+		Language:  c.Query("language"),
+		Sort:      c.Query("sort"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"min_reported_gdp", &filter.MinReportedGdp},
+		{"max_reported_gdp", &filter.MaxReportedGdp},
+	} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid " + p.name,
+				Details: err.Error(),
+			})
+			return filter, false
+		}
+		*p.dst = &f
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	} {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid " + p.name,
+				Details: p.name + " must be a non-negative integer",
+			})
+			return filter, false
+		}
+		*p.dst = n
+	}
+	return filter, true
+}
+
 // Get /countries/:name
 func (h *CountryHandler) GetCountryName(c *gin.Context) {
 	name := c.Param("name")
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	if !asOf.IsZero() {
+		version, err := h.service.GetCountryByNameAsOf(name, asOf)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, models.ErrorResponse{
+					Error:   "Country not found",
+					Details: fmt.Sprintf("no version of %s at %s", name, asOf.Format(timeLayout)),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, h.mapVersionToResponse(version))
+		return
+	}
+
 	country, err := h.service.GetCountryByName(name)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -104,13 +389,52 @@ func (h *CountryHandler) GetCountryName(c *gin.Context) {
 			return
 		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal Server Errror",
+			Error:   "Internal Server Error",
 			Details: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, h.mapCountryToResponse(country))
+	currencies, err := h.service.GetCountryCurrencies(country.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	response := h.mapCountryToResponse(country)
+	for _, cur := range currencies {
+		cr := models.CurrencyResponse{Code: cur.Code}
+		if cur.Name.Valid {
+			cr.Name = &cur.Name.String
+		}
+		if cur.Symbol.Valid {
+			cr.Symbol = &cur.Symbol.String
+		}
+		response.Currencies = append(response.Currencies, cr)
+	}
+
+	languages, err := h.service.GetCountryLanguages(country.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	for _, lang := range languages {
+		lr := models.LanguageResponse{Code: lang.Code}
+		if lang.Iso6391.Valid {
+			lr.Iso6391 = &lang.Iso6391.String
+		}
+		if lang.Name.Valid {
+			lr.Name = &lang.Name.String
+		}
+		response.Languages = append(response.Languages, lr)
+	}
+
+	c.JSON(http.StatusOK, response)
 
 }
 
@@ -160,10 +484,37 @@ func (h *CountryHandler) GetStatus(c *gin.Context) {
 	}
 
 	if lastRefresh.Valid {
-		response.LastRefreshedAt = lastRefresh.Time.Format("2006-01-02T15:04:05Z")
+		response.LastRefreshedAt = lastRefresh.Time.Format(timeLayout)
 	} else {
 		response.LastRefreshedAt = "Never"
 	}
+	provider, err := h.service.GetLastRateProvider()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	if provider.Valid {
+		response.RateProvider = &provider.String
+	}
+	response.RateProviders = h.service.RateProviders()
+	if h.scheduler != nil {
+		response.Scheduler = mapSchedulerStatus(h.scheduler.Status())
+	}
+	for _, b := range h.service.UpstreamStatus() {
+		upstream := models.UpstreamStatus{
+			Name:                b.Name,
+			CircuitState:        b.State,
+			ConsecutiveFailures: b.ConsecutiveFailures,
+		}
+		if b.State != internal.BreakerClosed && !b.OpenedAt.IsZero() {
+			v := b.OpenedAt.UTC().Format(timeLayout)
+			upstream.OpenedAt = &v
+		}
+		response.Upstreams = append(response.Upstreams, upstream)
+	}
 
 	c.JSON(http.StatusOK, response)
 }
@@ -182,6 +533,484 @@ func (h *CountryHandler) GetImage(c *gin.Context) {
 	c.File(imagePath)
 }
 
+// GET /currencies/:code/rates
+func (h *CountryHandler) GetCurrencyRates(c *gin.Context) {
+	code := strings.ToUpper(c.Param("code"))
+	base := strings.ToUpper(c.DefaultQuery("base", "USD"))
+	interval := c.DefaultQuery("interval", internal.IntervalRaw)
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = parseTimeParam(v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid from",
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = parseTimeParam(v); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid to",
+				Details: err.Error(),
+			})
+			return
+		}
+		if len(v) == len(dateLayout) {
+			// a bare date includes the whole day
+			to = to.Add(24*time.Hour - time.Second)
+		}
+	}
+
+	points, err := h.service.GetRateHistory(code, base, from, to, interval)
+	if err != nil {
+		if errors.Is(err, internal.ErrUnknownInterval) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid interval",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	response := models.RateSeriesResponse{
+		Currency: code,
+		Base:     base,
+		Interval: interval,
+		From:     from.Format(timeLayout),
+		To:       to.Format(timeLayout),
+		Points:   make([]models.RatePointResponse, 0, len(points)),
+	}
+	for _, p := range points {
+		response.Points = append(response.Points, models.RatePointResponse{
+			Timestamp: p.At.Format(timeLayout),
+			Rate:      p.Rate,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// POST /gdp/import?file=<name> reads a CSV from the import directory;
+// without file the request body is imported as the CSV
+func (h *CountryHandler) ImportReportedGDP(c *gin.Context) {
+	var result internal.GDPImportResult
+	var err error
+	if name := c.Query("file"); name != "" {
+		result, err = h.service.ImportReportedGDPFromDir(c.Request.Context(), name)
+	} else {
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, maxGDPUploadBytes)
+		result, err = h.service.ImportReportedGDP(c.Request.Context(), body)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Error:   "GDP CSV too large",
+				Details: fmt.Sprintf("the request body may be at most %d bytes", tooLarge.Limit),
+			})
+		case errors.Is(err, internal.ErrBadImportFile), errors.Is(err, os.ErrNotExist):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid import file",
+				Details: err.Error(),
+			})
+		case errors.Is(err, internal.ErrInvalidGDPCSV):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid GDP CSV",
+				Details: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Details: err.Error(),
+			})
+		}
+		return
+	}
+
+	response := models.GDPImportResponse{
+		Rows:      result.Rows,
+		Updated:   result.Updated,
+		Unmatched: result.Unmatched,
+	}
+	if response.Unmatched == nil {
+		response.Unmatched = []string{}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /countries/:name/history
+func (h *CountryHandler) GetCountryHistory(c *gin.Context) {
+	name := c.Param("name")
+	versions, err := h.service.GetCountryHistory(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	if len(versions) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Country not found",
+			Details: fmt.Sprintf("no history recorded for %s", name),
+		})
+		return
+	}
+
+	response := models.CountryHistoryResponse{
+		Name:     versions[len(versions)-1].CountryName,
+		Versions: make([]models.CountryVersionResponse, 0, len(versions)),
+	}
+	for _, v := range versions {
+		version := models.CountryVersionResponse{
+			ValidFrom: v.ValidFrom.Format(timeLayout),
+			Country:   h.mapVersionToResponse(v),
+		}
+		if v.ValidTo.Valid {
+			t := v.ValidTo.Time.Format(timeLayout)
+			version.ValidTo = &t
+		}
+		response.Versions = append(response.Versions, version)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /countries/:name/neighbors
+func (h *CountryHandler) GetNeighbors(c *gin.Context) {
+	country, neighbors, err := h.service.GetNeighbors(c.Param("name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Country not found",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	response := models.NeighborsResponse{
+		Name:      country.Name,
+		Neighbors: make([]models.CountryResponse, 0, len(neighbors)),
+	}
+	for _, n := range neighbors {
+		response.Neighbors = append(response.Neighbors, h.mapCountryToResponse(n))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /countries/path?from=France&to=China&max_hops=10
+func (h *CountryHandler) GetBorderPath(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Missing country",
+			Details: "both from and to are required",
+		})
+		return
+	}
+	maxHops := internal.DefaultMaxHops
+	if v := c.Query("max_hops"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid max_hops",
+				Details: "max_hops must be a positive integer",
+			})
+			return
+		}
+		maxHops = n
+	}
+
+	path, err := h.service.FindBorderPath(from, to, maxHops)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Country not found",
+				Details: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, internal.ErrNoPath) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "No land route",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	response := models.BorderPathResponse{
+		From: path[0].Name,
+		To:   path[len(path)-1].Name,
+		Hops: len(path) - 1,
+		Path: make([]models.CountryResponse, 0, len(path)),
+	}
+	for _, country := range path {
+		response.Path = append(response.Path, h.mapCountryToResponse(country))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /countries/nearest?lat=6.5&lng=3.4&limit=5&by=centroid|capital
+func (h *CountryHandler) GetNearestCountries(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid lat",
+			Details: "lat must be a number between -90 and 90",
+		})
+		return
+	}
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid lng",
+			Details: "lng must be a number between -180 and 180",
+		})
+		return
+	}
+	limit := defaultNearestLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxNearestLimit {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid limit",
+				Details: fmt.Sprintf("limit must be between 1 and %d", maxNearestLimit),
+			})
+			return
+		}
+		limit = n
+	}
+
+	nearest, err := h.service.NearestCountries(lat, lng, limit, c.DefaultQuery("by", internal.DistanceCentroid))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, internal.ErrUnknownBasis) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Could not find nearest countries",
+			Details: err.Error(),
+		})
+		return
+	}
+	responses := make([]models.CountryResponse, 0, len(nearest))
+	for _, n := range nearest {
+		response := h.mapCountryToResponse(n.Country)
+		km := math.Round(n.DistanceKM*100) / 100
+		response.DistanceKM = &km
+		responses = append(responses, response)
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// GET /countries/distance?from=France&to=NG
+func (h *CountryHandler) GetDistance(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Missing country",
+			Details: "both from and to are required",
+		})
+		return
+	}
+	result, err := h.service.GetDistance(from, to)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Country not found",
+				Details: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, internal.ErrNoCoordinates) {
+			c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+				Error:   "Distance unavailable",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	response := models.DistanceResponse{
+		From: h.mapCountryToResponse(result.From),
+		To:   h.mapCountryToResponse(result.To),
+	}
+	if result.CentroidKM != nil {
+		km := math.Round(*result.CentroidKM*100) / 100
+		response.DistanceKM = &km
+		response.To.DistanceKM = &km
+	}
+	if result.CapitalKM != nil {
+		km := math.Round(*result.CapitalKM*100) / 100
+		response.CapitalDistanceKM = &km
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GET /countries/:name/flag
+func (h *CountryHandler) GetCountryFlag(c *gin.Context) {
+	country, flag, cached, err := h.service.GetCountryFlag(c.Param("name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Country not found",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+	if !country.FlagUrl.Valid {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Flag not found",
+			Details: fmt.Sprintf("%s has no flag", country.Name),
+		})
+		return
+	}
+	if !cached {
+		c.Redirect(http.StatusFound, country.FlagUrl.String)
+		return
+	}
+	// the file is named by its hash, so the hash is a strong validator;
+	// ServeFile answers If-None-Match with 304 from it
+	c.Header("ETag", `"`+flag.ContentHash+`"`)
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("Content-Type", flag.ContentType)
+	// flags are upstream content, often SVG; keep browsers from sniffing
+	// another type or running scripts embedded in them
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.File(flag.Path)
+}
+
+// GET /admin/quarantine?status=pending|accepted|all
+func (h *CountryHandler) GetQuarantine(c *gin.Context) {
+	entries, err := h.service.ListQuarantine(c.Request.Context(), c.DefaultQuery("status", internal.QuarantinePending))
+	if err != nil {
+		if errors.Is(err, internal.ErrUnknownQuarantineStatus) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid status",
+				Details: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Internal Server Error",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.QuarantineEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		responses = append(responses, mapQuarantineToResponse(e))
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// POST /admin/quarantine/:id/accept
+func (h *CountryHandler) AcceptQuarantine(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid quarantine id",
+			Details: err.Error(),
+		})
+		return
+	}
+	country, err := h.service.AcceptQuarantined(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Quarantine entry not found",
+				Details: err.Error(),
+			})
+		case errors.Is(err, internal.ErrAlreadyAccepted):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Quarantine entry already accepted",
+				Details: err.Error(),
+			})
+		case errors.Is(err, internal.ErrCannotAccept):
+			c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+				Error:   "Quarantine entry cannot be accepted",
+				Details: err.Error(),
+			})
+		case errors.Is(err, internal.ErrCodeConflict):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Quarantine entry conflicts with a stored country",
+				Details: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Internal Server Error",
+				Details: err.Error(),
+			})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, h.mapCountryToResponse(country))
+}
+
+// parseAsOf reads the optional as_of query parameter. A bare date means the
+// end of that day. It writes a 400 and returns false when the value is bad.
+func parseAsOf(c *gin.Context) (time.Time, bool) {
+	v := c.Query("as_of")
+	if v == "" {
+		return time.Time{}, true
+	}
+	asOf, err := parseTimeParam(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid as_of",
+			Details: err.Error(),
+		})
+		return time.Time{}, false
+	}
+	if len(v) == len(dateLayout) {
+		asOf = asOf.Add(24*time.Hour - time.Second)
+	}
+	return asOf, true
+}
+
+// parseTimeParam accepts a bare date (2026-09-01) or an RFC 3339 timestamp
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", v)
+	}
+	return t.UTC(), nil
+}
+
 // Helper function to map DB model to response model
 func (h *CountryHandler) mapCountryToResponse(country db.Country) models.CountryResponse {
 	response := models.CountryResponse{
@@ -191,7 +1020,24 @@ func (h *CountryHandler) mapCountryToResponse(country db.Country) models.Country
 	}
 
 	if country.LastRefreshedAt.Valid {
-		response.LastRefreshedAt = country.LastRefreshedAt.Time.Format("2006-01-02T15:04:05Z")
+		response.LastRefreshedAt = country.LastRefreshedAt.Time.Format(timeLayout)
+	}
+
+	if country.StaleSince.Valid {
+		v := country.StaleSince.Time.Format(timeLayout)
+		response.StaleSince = &v
+	}
+
+	if country.Alpha2Code.Valid {
+		response.Alpha2Code = &country.Alpha2Code.String
+	}
+
+	if country.Alpha3Code.Valid {
+		response.Alpha3Code = &country.Alpha3Code.String
+	}
+
+	if country.NumericCode.Valid {
+		response.NumericCode = &country.NumericCode.String
 	}
 
 	if country.Capital.Valid {
@@ -202,6 +1048,33 @@ func (h *CountryHandler) mapCountryToResponse(country db.Country) models.Country
 		response.Region = &country.Region.String
 	}
 
+	if country.Subregion.Valid {
+		response.Subregion = &country.Subregion.String
+	}
+
+	if country.Area.Valid {
+		v := ParseNullStringFloat(country.Area)
+		response.Area = &v
+	}
+
+	if country.Latitude.Valid && country.Longitude.Valid {
+		lat := ParseNullStringFloat(country.Latitude)
+		lng := ParseNullStringFloat(country.Longitude)
+		response.Latitude = &lat
+		response.Longitude = &lng
+	}
+
+	if country.CapitalLatitude.Valid && country.CapitalLongitude.Valid {
+		lat := ParseNullStringFloat(country.CapitalLatitude)
+		lng := ParseNullStringFloat(country.CapitalLongitude)
+		response.CapitalLatitude = &lat
+		response.CapitalLongitude = &lng
+	}
+
+	response.Timezones = internal.ParseJSONList(country.Timezones)
+	response.CallingCodes = internal.ParseJSONList(country.CallingCodes)
+	response.Borders = internal.ParseJSONList(country.Borders)
+
 	if country.CurrencyCode.Valid {
 		response.CurrencyCode = &country.CurrencyCode.String
 	}
@@ -216,9 +1089,137 @@ func (h *CountryHandler) mapCountryToResponse(country db.Country) models.Country
 		response.EstimatedGDP = &v
 	}
 
+	if country.ReportedGdp.Valid {
+		v := ParseNullStringFloat(country.ReportedGdp)
+		response.ReportedGDP = &v
+	}
+
+	if country.ReportedGdpYear.Valid {
+		v := country.ReportedGdpYear.Int32
+		response.ReportedGDPYear = &v
+	}
+
+	if country.GdpStrategy.Valid {
+		response.GDPStrategy = &country.GdpStrategy.String
+	}
+
+	if country.GdpMultiplier.Valid {
+		v := ParseNullStringFloat(country.GdpMultiplier)
+		response.GDPMultiplier = &v
+	}
+
 	if country.FlagUrl.Valid {
 		response.FlagURL = &country.FlagUrl.String
 	}
 
 	return response
 }
+
+// Helper function to map a history version to the response model; history
+// keeps only the codes of its currencies and languages
+func (h *CountryHandler) mapVersionToResponse(version db.CountryHistory) models.CountryResponse {
+	response := h.mapCountryToResponse(internal.HistoryToCountry(version))
+	for _, code := range internal.ParseJSONList(version.Currencies) {
+		response.Currencies = append(response.Currencies, models.CurrencyResponse{Code: code})
+	}
+	for _, code := range internal.ParseJSONList(version.Languages) {
+		response.Languages = append(response.Languages, models.LanguageResponse{Code: code})
+	}
+	return response
+}
+
+// Helper function to map a quarantined record to its response model
+func mapQuarantineToResponse(entry db.QuarantinedCountry) models.QuarantineEntryResponse {
+	response := models.QuarantineEntryResponse{
+		ID:        entry.ID,
+		Name:      entry.CountryName,
+		Reasons:   strings.Split(entry.Reason, "; "),
+		Record:    json.RawMessage(entry.Payload),
+		Status:    entry.Status,
+		CreatedAt: entry.CreatedAt.Format(timeLayout),
+	}
+	if entry.JobID.Valid {
+		response.JobID = &entry.JobID.Int64
+	}
+	if entry.AcceptedAt.Valid {
+		v := entry.AcceptedAt.Time.Format(timeLayout)
+		response.AcceptedAt = &v
+	}
+	return response
+}
+
+// Helper function to map a refresh job row to its response model
+func mapJobToResponse(job db.RefreshJob) models.RefreshJobResponse {
+	response := models.RefreshJobResponse{
+		ID:                   job.ID,
+		Status:               job.Status,
+		Source:               job.Source,
+		CountriesProcessed:   job.CountriesProcessed,
+		CountriesFailed:      job.CountriesFailed,
+		CountriesQuarantined: job.CountriesQuarantined,
+		CreatedAt:            job.CreatedAt.Format(timeLayout),
+	}
+
+	if job.Scope.Valid {
+		response.Scope = &job.Scope.String
+	}
+
+	if job.RateProvider.Valid {
+		response.RateProvider = &job.RateProvider.String
+	}
+
+	if job.ErrorMessage.Valid {
+		response.Error = &job.ErrorMessage.String
+	}
+
+	if job.StartedAt.Valid {
+		v := job.StartedAt.Time.Format(timeLayout)
+		response.StartedAt = &v
+	}
+
+	if job.FinishedAt.Valid {
+		v := job.FinishedAt.Time.Format(timeLayout)
+		response.FinishedAt = &v
+		if job.StartedAt.Valid {
+			d := job.FinishedAt.Time.Sub(job.StartedAt.Time).Milliseconds()
+			response.DurationMs = &d
+		}
+	}
+
+	if job.Status == internal.JobSucceeded {
+		response.Changes = &models.ChangeSummary{
+			Added:    job.CountriesAdded,
+			Removed:  job.CountriesRemoved,
+			Modified: job.CountriesModified,
+		}
+	}
+
+	return response
+}
+
+// Helper function to map scheduler state to its response model
+func mapSchedulerStatus(status internal.SchedulerStatus) *models.SchedulerStatus {
+	response := &models.SchedulerStatus{
+		Schedule: status.Schedule,
+	}
+
+	if !status.NextRun.IsZero() {
+		v := status.NextRun.UTC().Format(timeLayout)
+		response.NextRunAt = &v
+	}
+
+	if !status.LastRun.IsZero() {
+		v := status.LastRun.UTC().Format(timeLayout)
+		response.LastRunAt = &v
+	}
+
+	if status.LastJobID != 0 {
+		response.LastJobID = &status.LastJobID
+	}
+
+	if status.LastOutcome != "" {
+		response.LastOutcome = &status.LastOutcome
+	}
+
+	return response
+}
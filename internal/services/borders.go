@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// DefaultMaxHops bounds a border path search when the caller sets no limit
+const DefaultMaxHops = 10
+
+// ErrNoPath is returned when no land route joins two countries within the
+// hop limit
+var ErrNoPath = errors.New("no land route between the countries")
+
+// borderGraph indexes stored countries by alpha-3 code, the key restcountries
+// uses for borders
+type borderGraph map[string]db.Country
+
+func (c *CountryService) loadBorderGraph(ctx context.Context) (borderGraph, error) {
+	countries, err := c.q.GetAllCountries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load countries: %w", err)
+	}
+	graph := make(borderGraph, len(countries))
+	for _, country := range countries {
+		if country.Alpha3Code.Valid {
+			graph[strings.ToUpper(country.Alpha3Code.String)] = country
+		}
+	}
+	return graph, nil
+}
+
+// neighbors lists the stored countries bordering a country, in upstream
+// order. Borders pointing at countries that are not stored are skipped.
+func (g borderGraph) neighbors(country db.Country) []db.Country {
+	var out []db.Country
+	for _, code := range ParseJSONList(country.Borders) {
+		if n, ok := g[strings.ToUpper(code)]; ok {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// function to get the countries bordering a country, by name or ISO code
+func (c *CountryService) GetNeighbors(name string) (db.Country, []db.Country, error) {
+	ctx := context.Background()
+	country, err := findCountry(ctx, c.q, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Country{}, nil, err
+		}
+		return db.Country{}, nil, fmt.Errorf("could not get country: %w", err)
+	}
+	graph, err := c.loadBorderGraph(ctx)
+	if err != nil {
+		return db.Country{}, nil, err
+	}
+	return country, graph.neighbors(country), nil
+}
+
+// FindBorderPath returns the shortest chain of bordering countries from one
+// country to another, both ends included, using at most maxHops crossings.
+// Returns sql.ErrNoRows if either country is unknown and ErrNoPath if they
+// are not joined by land within the limit.
+func (c *CountryService) FindBorderPath(from, to string, maxHops int) ([]db.Country, error) {
+	ctx := context.Background()
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
+	start, err := findCountry(ctx, c.q, from)
+	if err != nil {
+		return nil, err
+	}
+	goal, err := findCountry(ctx, c.q, to)
+	if err != nil {
+		return nil, err
+	}
+	if start.ID == goal.ID {
+		return []db.Country{start}, nil
+	}
+	if !start.Alpha3Code.Valid || !goal.Alpha3Code.Valid {
+		return nil, fmt.Errorf("%w: border data is missing for %s or %s", ErrNoPath, start.Name, goal.Name)
+	}
+	graph, err := c.loadBorderGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path := graph.shortestPath(start.Alpha3Code.String, goal.Alpha3Code.String, maxHops)
+	if path == nil {
+		return nil, fmt.Errorf("%w: %s to %s within %d hops", ErrNoPath, start.Name, goal.Name, maxHops)
+	}
+	return path, nil
+}
+
+// shortestPath finds the fewest crossings between two alpha-3 codes, or
+// returns nil when the goal is not reached within maxHops. The search is
+// breadth-first, so the first time the goal is reached is along a shortest
+// path.
+func (g borderGraph) shortestPath(from, to string, maxHops int) []db.Country {
+	startCode, goalCode := strings.ToUpper(from), strings.ToUpper(to)
+	if startCode == goalCode {
+		return g.walkBack(map[string]string{}, startCode)
+	}
+	parent := map[string]string{startCode: ""}
+	frontier := []string{startCode}
+	for hops := 0; hops < maxHops && len(frontier) > 0; hops++ {
+		var next []string
+		for _, code := range frontier {
+			for _, n := range g.neighbors(g[code]) {
+				nc := strings.ToUpper(n.Alpha3Code.String)
+				if _, seen := parent[nc]; seen {
+					continue
+				}
+				parent[nc] = code
+				if nc == goalCode {
+					return g.walkBack(parent, nc)
+				}
+				next = append(next, nc)
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+// walkBack follows parent links from code to the search start and returns
+// the countries in travel order
+func (g borderGraph) walkBack(parent map[string]string, code string) []db.Country {
+	var path []db.Country
+	for ; code != ""; code = parent[code] {
+		path = append(path, g[code])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// testBorderGraph is a small map of West Africa plus an island:
+//
+//	SEN - MLI - BFA - GHA
+//	       |     |     |
+//	      CIV ------- TGO - BEN
+//	CPV
+var testBorderGraph = func() borderGraph {
+	borders := map[string][]string{
+		"SEN": {"MLI"},
+		"MLI": {"SEN", "BFA", "CIV"},
+		"BFA": {"MLI", "GHA", "TGO"},
+		"GHA": {"BFA", "TGO"},
+		"CIV": {"MLI", "TGO", "XXX"},
+		"TGO": {"BFA", "GHA", "CIV", "BEN"},
+		"BEN": {"TGO"},
+		"CPV": nil,
+	}
+	g := borderGraph{}
+	for code, list := range borders {
+		g[code] = db.Country{Name: code, Alpha3Code: nullString(code), Borders: jsonList(list)}
+	}
+	return g
+}()
+
+func pathCodes(path []db.Country) []string {
+	var codes []string
+	for _, c := range path {
+		codes = append(codes, c.Name)
+	}
+	return codes
+}
+
+func TestShortestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		maxHops  int
+		want     []string
+	}{
+		{"neighbours", "GHA", "TGO", 10, []string{"GHA", "TGO"}},
+		{"fewest crossings", "SEN", "BEN", 10, []string{"SEN", "MLI", "BFA", "TGO", "BEN"}},
+		{"codes are case-insensitive", "sen", "bfa", 10, []string{"SEN", "MLI", "BFA"}},
+		{"same country", "GHA", "GHA", 10, []string{"GHA"}},
+		{"exactly the hop limit", "SEN", "BEN", 4, []string{"SEN", "MLI", "BFA", "TGO", "BEN"}},
+		{"beyond the hop limit", "SEN", "BEN", 3, nil},
+		{"island", "CPV", "SEN", 10, nil},
+		{"unknown border code", "CIV", "XXX", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pathCodes(testBorderGraph.shortestPath(tt.from, tt.to, tt.maxHops))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("shortestPath(%s, %s, %d) = %v, want %v", tt.from, tt.to, tt.maxHops, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	got := pathCodes(testBorderGraph.neighbors(testBorderGraph["CIV"]))
+	if want := []string{"MLI", "TGO"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("neighbors(CIV) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// Change types stored in refresh_changes.change_type
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// FieldChange is one column whose value differs between refreshes
+type FieldChange struct {
+	Field string
+	Old   sql.NullString
+	New   sql.NullString
+}
+
+// CountryChange describes how one country differs from the previous state
+type CountryChange struct {
+	// ID is the existing row's id; zero for added countries
+	ID     int64
+	Name   string
+	Type   string
+	Fields []FieldChange
+}
+
+// ChangeReport is the diff between the countries table before and after a
+// refresh
+type ChangeReport struct {
+	Added    int
+	Removed  int
+	Modified int
+	Changes  []CountryChange
+}
+
+// languageSets holds the language codes of countries before a refresh, by
+// id, and after it, by lower-cased name. Languages live in their own table,
+// so they are compared apart from the country columns; the zero value
+// compares nothing.
+type languageSets struct {
+	before map[int64]sql.NullString
+	after  map[string]sql.NullString
+}
+
+// change reports whether a country's languages differ across the refresh
+func (l languageSets) change(id int64, name string) (FieldChange, bool) {
+	if l.after == nil {
+		return FieldChange{}, false
+	}
+	oldVal, newVal := l.before[id], l.after[strings.ToLower(name)]
+	return FieldChange{Field: "languages", Old: oldVal, New: newVal}, oldVal != newVal
+}
+
+// diffCountries compares the previous rows with the rows that were written.
+// Rows named in failed were returned upstream but not saved: they are
+// neither reported as changed nor treated as missing. The held names were
+// returned upstream but quarantined, so they are not missing either. Names
+// are matched case-insensitively, like the countries.name unique key; a row
+// with a new name but the ISO codes of a previous country is that country
+// renamed, as planRenames stores it.
+func diffCountries(previous []db.Country, rows []db.UpsertCountryParams, failed map[string]bool, held []string, languages languageSets) ChangeReport {
+	var report ChangeReport
+	diff := func(prev db.Country, row db.UpsertCountryParams) []FieldChange {
+		fields := diffFields(prev, row)
+		if f, ok := languages.change(prev.ID, row.Name); ok {
+			fields = append(fields, f)
+		}
+		return fields
+	}
+
+	old := make(map[string]db.Country, len(previous))
+	for _, country := range previous {
+		old[strings.ToLower(country.Name)] = country
+	}
+	codes := newCodeIndex(previous)
+
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		key := strings.ToLower(row.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if failed[row.Name] {
+			continue
+		}
+
+		prev, ok := old[key]
+		if !ok {
+			if o, err := codes.owner(row); err == nil && o != nil && !seen[strings.ToLower(o.Name)] {
+				prev = old[strings.ToLower(o.Name)]
+				seen[strings.ToLower(o.Name)] = true
+				fields := append([]FieldChange{{Field: "name", Old: nullString(prev.Name), New: nullString(row.Name)}}, diff(prev, row)...)
+				report.Modified++
+				report.Changes = append(report.Changes, CountryChange{ID: prev.ID, Name: row.Name, Type: ChangeModified, Fields: fields})
+				continue
+			}
+			report.Added++
+			report.Changes = append(report.Changes, CountryChange{Name: row.Name, Type: ChangeAdded})
+			continue
+		}
+		if fields := diff(prev, row); len(fields) > 0 {
+			report.Modified++
+			report.Changes = append(report.Changes, CountryChange{ID: prev.ID, Name: row.Name, Type: ChangeModified, Fields: fields})
+		}
+	}
+
+	for _, name := range held {
+		seen[strings.ToLower(name)] = true
+	}
+
+	// rows already marked stale were reported as removed by an earlier refresh
+	removed := make([]db.Country, 0)
+	for key, country := range old {
+		if !seen[key] && !country.StaleSince.Valid {
+			removed = append(removed, country)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Name < removed[j].Name })
+	for _, country := range removed {
+		report.Removed++
+		report.Changes = append(report.Changes, CountryChange{ID: country.ID, Name: country.Name, Type: ChangeRemoved})
+	}
+
+	return report
+}
+
+// diffFields lists the columns a refresh would change for one country
+func diffFields(prev db.Country, row db.UpsertCountryParams) []FieldChange {
+	var fields []FieldChange
+	add := func(field string, oldVal, newVal sql.NullString) {
+		if oldVal != newVal {
+			fields = append(fields, FieldChange{Field: field, Old: oldVal, New: newVal})
+		}
+	}
+
+	add("capital", prev.Capital, row.Capital)
+	add("region", prev.Region, row.Region)
+	add("subregion", prev.Subregion, row.Subregion)
+	add("population", int64String(prev.Population), int64String(row.Population))
+	add("currency_code", prev.CurrencyCode, row.CurrencyCode)
+	add("exchange_rate", prev.ExchangeRate, row.ExchangeRate)
+	add("estimated_gdp", prev.EstimatedGdp, row.EstimatedGdp)
+	add("flag_url", prev.FlagUrl, row.FlagUrl)
+	add("alpha2_code", prev.Alpha2Code, row.Alpha2Code)
+	add("alpha3_code", prev.Alpha3Code, row.Alpha3Code)
+	add("numeric_code", prev.NumericCode, row.NumericCode)
+	add("area", prev.Area, row.Area)
+	add("latitude", prev.Latitude, row.Latitude)
+	add("longitude", prev.Longitude, row.Longitude)
+	add("capital_latitude", prev.CapitalLatitude, row.CapitalLatitude)
+	add("capital_longitude", prev.CapitalLongitude, row.CapitalLongitude)
+	add("timezones", prev.Timezones, row.Timezones)
+	add("calling_codes", prev.CallingCodes, row.CallingCodes)
+	add("borders", prev.Borders, row.Borders)
+	return fields
+}
+
+func int64String(v int64) sql.NullString {
+	return sql.NullString{String: strconv.FormatInt(v, 10), Valid: true}
+}
+
+// RemovedIDs lists the ids of countries missing from the upstream payload
+func (r ChangeReport) RemovedIDs() []int64 {
+	ids := make([]int64, 0, r.Removed)
+	for _, change := range r.Changes {
+		if change.Type == ChangeRemoved {
+			ids = append(ids, change.ID)
+		}
+	}
+	return ids
+}
+
+// changeRows flattens a report into refresh_changes rows for a job
+func changeRows(jobID int64, report ChangeReport) []db.CreateRefreshChangeParams {
+	rows := make([]db.CreateRefreshChangeParams, 0, len(report.Changes))
+	for _, change := range report.Changes {
+		if len(change.Fields) == 0 {
+			rows = append(rows, db.CreateRefreshChangeParams{
+				JobID:       jobID,
+				CountryName: change.Name,
+				ChangeType:  change.Type,
+			})
+			continue
+		}
+		for _, f := range change.Fields {
+			rows = append(rows, db.CreateRefreshChangeParams{
+				JobID:       jobID,
+				CountryName: change.Name,
+				ChangeType:  change.Type,
+				Field:       sql.NullString{String: f.Field, Valid: true},
+				OldValue:    f.Old,
+				NewValue:    f.New,
+			})
+		}
+	}
+	return rows
+}
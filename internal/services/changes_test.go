@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func storedCountry(id int64, name, capital string) db.Country {
+	return db.Country{ID: id, Name: name, Capital: nullString(capital), Population: 1000}
+}
+
+func upsertRow(name, capital string) db.UpsertCountryParams {
+	return db.UpsertCountryParams{Name: name, Capital: nullString(capital), Population: 1000}
+}
+
+// changeList flattens a report to "type name" entries for comparison
+func changeList(r ChangeReport) []string {
+	var out []string
+	for _, c := range r.Changes {
+		out = append(out, c.Type+" "+c.Name)
+	}
+	return out
+}
+
+func TestDiffCountries(t *testing.T) {
+	stale := storedCountry(4, "Atlantis", "Poseidonia")
+	stale.StaleSince = sql.NullTime{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name     string
+		previous []db.Country
+		rows     []db.UpsertCountryParams
+		failed   map[string]bool
+		held     []string
+		want     []string
+		counts   [3]int // added, removed, modified
+	}{
+		{
+			name:     "unchanged",
+			previous: []db.Country{storedCountry(1, "Ghana", "Accra")},
+			rows:     []db.UpsertCountryParams{upsertRow("Ghana", "Accra")},
+		},
+		{
+			name:     "added, modified and removed",
+			previous: []db.Country{storedCountry(1, "Ghana", "Accra"), storedCountry(2, "Togo", "Lomé")},
+			rows:     []db.UpsertCountryParams{upsertRow("Ghana", "Kumasi"), upsertRow("Benin", "Porto-Novo")},
+			want:     []string{"modified Ghana", "added Benin", "removed Togo"},
+			counts:   [3]int{1, 1, 1},
+		},
+		{
+			name:     "names match case-insensitively",
+			previous: []db.Country{storedCountry(1, "Ghana", "Accra")},
+			rows:     []db.UpsertCountryParams{upsertRow("GHANA", "Accra")},
+		},
+		{
+			name:     "duplicate rows count once",
+			previous: nil,
+			rows:     []db.UpsertCountryParams{upsertRow("Ghana", "Accra"), upsertRow("ghana", "Accra")},
+			want:     []string{"added Ghana"},
+			counts:   [3]int{1, 0, 0},
+		},
+		{
+			name:     "failed rows are neither changed nor missing",
+			previous: []db.Country{storedCountry(1, "Ghana", "Accra")},
+			rows:     []db.UpsertCountryParams{upsertRow("Ghana", "Kumasi"), upsertRow("Benin", "Porto-Novo")},
+			failed:   map[string]bool{"Ghana": true, "Benin": true},
+		},
+		{
+			name:     "held rows are not missing",
+			previous: []db.Country{storedCountry(1, "Ghana", "Accra")},
+			held:     []string{"ghana"},
+		},
+		{
+			name:     "stale rows are not removed again",
+			previous: []db.Country{stale},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := diffCountries(tt.previous, tt.rows, tt.failed, tt.held, languageSets{})
+			if got := changeList(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+			if got := [3]int{report.Added, report.Removed, report.Modified}; got != tt.counts {
+				t.Errorf("added, removed, modified = %v, want %v", got, tt.counts)
+			}
+		})
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	prev := storedCountry(1, "Ghana", "Accra")
+	prev.Alpha3Code = nullString("GHA")
+	row := upsertRow("Ghana", "Kumasi")
+	row.Population = 2000
+	row.Alpha3Code = nullString("GHA")
+	prev.Latitude, row.Latitude = nullString("8.000000"), nullString("7.946500")
+	prev.Longitude, row.Longitude = nullString("-2.000000"), nullString("-2.000000")
+	row.Timezones = jsonList([]string{"UTC"})
+	prev.CallingCodes, row.CallingCodes = jsonList([]string{"+233"}), jsonList([]string{"+233"})
+
+	want := []FieldChange{
+		{Field: "capital", Old: nullString("Accra"), New: nullString("Kumasi")},
+		{Field: "population", Old: nullString("1000"), New: nullString("2000")},
+		{Field: "latitude", Old: nullString("8.000000"), New: nullString("7.946500")},
+		{Field: "timezones", Old: sql.NullString{}, New: jsonList([]string{"UTC"})},
+	}
+	if got := diffFields(prev, row); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffCountriesLanguages(t *testing.T) {
+	previous := []db.Country{storedCountry(1, "Ghana", "Accra"), storedCountry(2, "Togo", "Lomé")}
+	rows := []db.UpsertCountryParams{upsertRow("Ghana", "Accra"), upsertRow("Togo", "Lomé"), upsertRow("Benin", "Porto-Novo")}
+	languages := languageSets{
+		before: map[int64]sql.NullString{1: jsonList([]string{"eng"}), 2: jsonList([]string{"fra"})},
+		after: map[string]sql.NullString{
+			"ghana": jsonList([]string{"eng", "twi"}),
+			"togo":  jsonList([]string{"fra"}),
+			"benin": jsonList([]string{"fra"}),
+		},
+	}
+
+	report := diffCountries(previous, rows, nil, nil, languages)
+	if got, want := changeList(report), []string{"modified Ghana", "added Benin"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+	want := []FieldChange{{Field: "languages", Old: jsonList([]string{"eng"}), New: jsonList([]string{"eng", "twi"})}}
+	if got := report.Changes[0].Fields; !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %+v, want %+v", got, want)
+	}
+
+	if report := diffCountries(previous, rows, nil, nil, languageSets{}); report.Modified != 0 {
+		t.Errorf("zero languageSets reported %q", changeList(report))
+	}
+}
+
+func TestChangeRowsAndRemovedIDs(t *testing.T) {
+	report := ChangeReport{Changes: []CountryChange{
+		{Name: "Benin", Type: ChangeAdded},
+		{ID: 1, Name: "Ghana", Type: ChangeModified, Fields: []FieldChange{
+			{Field: "capital", Old: nullString("Accra"), New: nullString("Kumasi")},
+			{Field: "region", Old: nullString("Africa"), New: sql.NullString{}},
+		}},
+		{ID: 2, Name: "Togo", Type: ChangeRemoved},
+		{ID: 3, Name: "Niger", Type: ChangeRemoved},
+	}, Removed: 2}
+
+	rows := changeRows(7, report)
+	if len(rows) != 5 {
+		t.Fatalf("changeRows returned %d rows, want 5", len(rows))
+	}
+	for _, r := range rows {
+		if r.JobID != 7 {
+			t.Errorf("row %+v has job %d, want 7", r, r.JobID)
+		}
+	}
+	if rows[0].Field.Valid || rows[3].Field.Valid {
+		t.Errorf("added and removed rows should have no field: %+v", rows)
+	}
+	if rows[2].Field.String != "region" || rows[2].NewValue.Valid {
+		t.Errorf("field row = %+v, want region cleared", rows[2])
+	}
+
+	if got, want := report.RemovedIDs(), []int64{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RemovedIDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	type step struct {
+		op        string // allow, success, failure or cool (let the cooldown pass)
+		wantErr   error
+		wantState string
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{
+			name:      "opens at the threshold",
+			threshold: 3,
+			steps: []step{
+				{op: "failure", wantState: BreakerClosed},
+				{op: "failure", wantState: BreakerClosed},
+				{op: "allow", wantState: BreakerClosed},
+				{op: "failure", wantState: BreakerOpen},
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: BreakerOpen},
+			},
+		},
+		{
+			name:      "success resets the count",
+			threshold: 2,
+			steps: []step{
+				{op: "failure", wantState: BreakerClosed},
+				{op: "success", wantState: BreakerClosed},
+				{op: "failure", wantState: BreakerClosed},
+				{op: "failure", wantState: BreakerOpen},
+			},
+		},
+		{
+			name:      "one half-open trial that closes",
+			threshold: 1,
+			steps: []step{
+				{op: "failure", wantState: BreakerOpen},
+				{op: "cool", wantState: BreakerHalfOpen},
+				{op: "allow", wantState: BreakerHalfOpen},
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: BreakerHalfOpen},
+				{op: "success", wantState: BreakerClosed},
+				{op: "allow", wantState: BreakerClosed},
+			},
+		},
+		{
+			name:      "failed trial re-opens below the threshold",
+			threshold: 5,
+			steps: []step{
+				{op: "failure"}, {op: "failure"}, {op: "failure"}, {op: "failure"},
+				{op: "failure", wantState: BreakerOpen},
+				{op: "cool", wantState: BreakerHalfOpen},
+				{op: "allow", wantState: BreakerHalfOpen},
+				{op: "failure", wantState: BreakerOpen},
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: BreakerOpen},
+			},
+		},
+		{
+			name:      "threshold below one is one",
+			threshold: 0,
+			steps: []step{
+				{op: "failure", wantState: BreakerOpen},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("test", tt.threshold, time.Hour)
+			for i, s := range tt.steps {
+				var err error
+				switch s.op {
+				case "allow":
+					err = b.Allow()
+				case "success":
+					b.Success()
+				case "failure":
+					b.Failure()
+				case "cool":
+					b.openedAt = b.openedAt.Add(-time.Hour)
+				}
+				if !errors.Is(err, s.wantErr) {
+					t.Fatalf("step %d (%s): err = %v, want %v", i, s.op, err, s.wantErr)
+				}
+				if s.wantState == "" {
+					continue
+				}
+				if got := b.Snapshot().State; got != s.wantState {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, s.op, got, s.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSnapshot(t *testing.T) {
+	b := NewCircuitBreaker("rates", 2, time.Hour)
+	b.Failure()
+	b.Failure()
+	snap := b.Snapshot()
+	if snap.Name != "rates" || snap.ConsecutiveFailures != 2 || snap.OpenedAt.IsZero() {
+		t.Errorf("Snapshot = %+v", snap)
+	}
+}
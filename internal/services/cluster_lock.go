@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// DefaultRefreshLockName is the MySQL named lock held while a refresh runs
+const DefaultRefreshLockName = "countries_refresh"
+
+// DefaultLockTimeout is how long a refresh waits for the lock
+const DefaultLockTimeout = 10 * time.Second
+
+// ErrLockTimeout is returned when the lock stays held by another session
+var ErrLockTimeout = errors.New("timed out waiting for the refresh lock")
+
+// ClusterLock is a MySQL GET_LOCK named lock. It is shared by every replica
+// using the same database. Named locks belong to a session, so the lock
+// keeps a dedicated connection until it is released; if the holder dies,
+// MySQL frees the lock with the connection.
+type ClusterLock struct {
+	conn    *sql.DB
+	name    string
+	timeout time.Duration
+}
+
+func NewClusterLock(conn *sql.DB, name string, timeout time.Duration) *ClusterLock {
+	return &ClusterLock{conn: conn, name: name, timeout: timeout}
+}
+
+// Acquire waits up to the lock timeout and returns a release function
+func (l *ClusterLock) Acquire(ctx context.Context) (func(), error) {
+	return l.acquire(ctx, int32(math.Ceil(l.timeout.Seconds())))
+}
+
+// TryAcquire takes the lock only if it is free right now
+func (l *ClusterLock) TryAcquire(ctx context.Context) (func(), error) {
+	return l.acquire(ctx, 0)
+}
+
+func (l *ClusterLock) acquire(ctx context.Context, seconds int32) (func(), error) {
+	conn, err := l.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not reserve a connection for lock %s: %w", l.name, err)
+	}
+	q := db.New(conn)
+	acquired, err := q.GetLock(ctx, db.GetLockParams{Name: l.name, Timeout: seconds})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not take lock %s: %w", l.name, err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return func() {
+		if err := q.ReleaseLock(context.Background(), l.name); err != nil {
+			log.Printf("could not release lock %s: %v", l.name, err)
+		}
+		conn.Close()
+	}, nil
+}
+
+// Held reports whether any session currently holds the lock
+func (l *ClusterLock) Held(ctx context.Context) (bool, error) {
+	free, err := db.New(l.conn).IsFreeLock(ctx, l.name)
+	if err != nil {
+		return false, fmt.Errorf("could not check lock %s: %w", l.name, err)
+	}
+	return free.Valid && free.Int64 == 0, nil
+}
+
+// Timeout is how long Acquire waits
+func (l *ClusterLock) Timeout() time.Duration {
+	return l.timeout
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/franzego/stage02/models"
+)
+
+// restcountries response schemas understood by decodeCountries
+const (
+	FormatV2  = "v2"
+	FormatV31 = "v3.1"
+)
+
+// Fields of the details request, which restcountries answers separately
+// because it limits how many fields one request may ask for
+const (
+	DetailFieldsV2  = "alpha3Code,subregion,area,latlng,languages,timezones,callingCodes,borders"
+	DetailFieldsV31 = "cca3,subregion,area,latlng,languages,timezones,idd,borders,capitalInfo"
+)
+
+// ErrUnknownFormat is returned for an unsupported restcountries schema
+var ErrUnknownFormat = errors.New("unknown restcountries format")
+
+// ValidateFormat checks a restcountries schema name
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatV2, FormatV31:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// decodeCountries reads a restcountries payload in the given schema and maps
+// it onto CountryData, so the rest of the refresh never sees the difference
+func decodeCountries(r io.Reader, format string) ([]models.CountryData, error) {
+	switch format {
+	case FormatV2:
+		var countries []models.CountryData
+		if err := json.NewDecoder(r).Decode(&countries); err != nil {
+			return nil, err
+		}
+		return countries, nil
+	case FormatV31:
+		var raw []models.CountryDataV3
+		if err := json.NewDecoder(r).Decode(&raw); err != nil {
+			return nil, err
+		}
+		countries := make([]models.CountryData, 0, len(raw))
+		for _, c := range raw {
+			countries = append(countries, fromV3(c))
+		}
+		return countries, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// fromV3 maps a v3.1 record onto the v2 shaped CountryData
+func fromV3(c models.CountryDataV3) models.CountryData {
+	country := models.CountryData{
+		Name:          c.Name.Common,
+		Region:        c.Region,
+		Population:    c.Population,
+		Flag:          c.Flags.SVG,
+		Independent:   c.Independent,
+		Alpha2Code:    c.Cca2,
+		Alpha3Code:    c.Cca3,
+		NumericCode:   c.Ccn3,
+		Subregion:     c.Subregion,
+		Area:          c.Area,
+		Latlng:        c.Latlng,
+		Timezones:     c.Timezones,
+		Borders:       c.Borders,
+		CapitalLatlng: c.CapitalInfo.Latlng,
+	}
+	if len(c.Capital) > 0 {
+		country.Capital = c.Capital[0]
+	}
+	if country.Flag == "" {
+		country.Flag = c.Flags.PNG
+	}
+
+	// v3.1 keys currencies by code; sort them so the primary currency is
+	// stable between refreshes
+	codes := make([]string, 0, len(c.Currencies))
+	for code := range c.Currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		cur := c.Currencies[code]
+		country.Currencies = append(country.Currencies, models.Currency{
+			Code:      code,
+			Name:      cur.Name,
+			SymbolUrl: cur.Symbol,
+		})
+	}
+
+	// v3.1 only has ISO 639-3 keys; iso639_1 stays empty
+	langs := make([]string, 0, len(c.Languages))
+	for code := range c.Languages {
+		langs = append(langs, code)
+	}
+	sort.Strings(langs)
+	for _, code := range langs {
+		country.Languages = append(country.Languages, models.Language{Iso6392: code, Name: c.Languages[code]})
+	}
+
+	// a root with one suffix is a full prefix (+234); with several, as for
+	// +1, the suffixes are area codes and the root alone is the prefix
+	root := strings.TrimPrefix(c.Idd.Root, "+")
+	if len(c.Idd.Suffixes) == 1 {
+		country.CallingCodes = []string{root + c.Idd.Suffixes[0]}
+	} else if root != "" {
+		country.CallingCodes = []string{root}
+	}
+	return country
+}
+
+// mergeDetails copies the attributes fetched by a second, details-only
+// request onto the matching records, keyed by alpha-3 code. restcountries
+// caps the fields of a single request, so the attributes come separately.
+func mergeDetails(countries, details []models.CountryData) {
+	byCode := make(map[string]models.CountryData, len(details))
+	for _, d := range details {
+		if d.Alpha3Code != "" {
+			byCode[strings.ToUpper(d.Alpha3Code)] = d
+		}
+	}
+	for i := range countries {
+		d, ok := byCode[strings.ToUpper(countries[i].Alpha3Code)]
+		if !ok {
+			continue
+		}
+		countries[i].Subregion = d.Subregion
+		countries[i].Area = d.Area
+		countries[i].Latlng = d.Latlng
+		countries[i].Languages = d.Languages
+		countries[i].Timezones = d.Timezones
+		countries[i].CallingCodes = d.CallingCodes
+		countries[i].Borders = d.Borders
+		countries[i].CapitalLatlng = d.CapitalLatlng
+	}
+}
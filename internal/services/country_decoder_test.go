@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/franzego/stage02/models"
+)
+
+func TestDecodeCountriesV31(t *testing.T) {
+	payload := `[{
+		"name": {"common": "Ghana", "official": "Republic of Ghana"},
+		"capital": ["Accra"],
+		"region": "Africa",
+		"subregion": "Western Africa",
+		"population": 31072945,
+		"flags": {"png": "https://flagcdn.com/w320/gh.png", "svg": "https://flagcdn.com/gh.svg"},
+		"currencies": {"GHS": {"name": "Ghanaian cedi", "symbol": "₵"}},
+		"independent": true,
+		"cca2": "GH", "cca3": "GHA", "ccn3": "288",
+		"area": 238533,
+		"latlng": [8, -2],
+		"languages": {"eng": "English"},
+		"timezones": ["UTC"],
+		"idd": {"root": "+2", "suffixes": ["33"]},
+		"borders": ["BFA", "CIV", "TGO"],
+		"capitalInfo": {"latlng": [5.55, -0.22]}
+	}]`
+	got, err := decodeCountries(strings.NewReader(payload), FormatV31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := 238533.0
+	want := []models.CountryData{{
+		Name:          "Ghana",
+		Capital:       "Accra",
+		Region:        "Africa",
+		Population:    31072945,
+		Flag:          "https://flagcdn.com/gh.svg",
+		Currencies:    []models.Currency{{Code: "GHS", Name: "Ghanaian cedi", SymbolUrl: "₵"}},
+		Independent:   true,
+		Alpha2Code:    "GH",
+		Alpha3Code:    "GHA",
+		NumericCode:   "288",
+		Subregion:     "Western Africa",
+		Area:          &area,
+		Latlng:        []float64{8, -2},
+		Languages:     []models.Language{{Iso6392: "eng", Name: "English"}},
+		Timezones:     []string{"UTC"},
+		CallingCodes:  []string{"233"},
+		Borders:       []string{"BFA", "CIV", "TGO"},
+		CapitalLatlng: []float64{5.55, -0.22},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeCountries =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFromV3(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    models.CountryDataV3
+		check func(t *testing.T, c models.CountryData)
+	}{
+		{
+			name: "png flag when there is no svg",
+			in:   v3WithFlags("https://flagcdn.com/w320/gh.png", ""),
+			check: func(t *testing.T, c models.CountryData) {
+				if c.Flag != "https://flagcdn.com/w320/gh.png" {
+					t.Errorf("Flag = %q", c.Flag)
+				}
+			},
+		},
+		{
+			name: "currencies and languages in code order",
+			in: models.CountryDataV3{
+				Currencies: map[string]models.CurrencyV3{"ZAR": {}, "BWP": {}, "NAD": {}},
+				Languages:  map[string]string{"nld": "Dutch", "afr": "Afrikaans"},
+			},
+			check: func(t *testing.T, c models.CountryData) {
+				var codes []string
+				for _, cur := range c.Currencies {
+					codes = append(codes, cur.Code)
+				}
+				if !reflect.DeepEqual(codes, []string{"BWP", "NAD", "ZAR"}) {
+					t.Errorf("currencies = %v", codes)
+				}
+				if c.Languages[0].Iso6392 != "afr" || c.Languages[1].Iso6392 != "nld" {
+					t.Errorf("languages = %+v", c.Languages)
+				}
+			},
+		},
+		{
+			name: "no capital",
+			in:   models.CountryDataV3{},
+			check: func(t *testing.T, c models.CountryData) {
+				if c.Capital != "" || c.CallingCodes != nil {
+					t.Errorf("Capital = %q, CallingCodes = %v", c.Capital, c.CallingCodes)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, fromV3(tt.in))
+		})
+	}
+}
+
+func v3WithFlags(png, svg string) models.CountryDataV3 {
+	var c models.CountryDataV3
+	c.Flags.PNG = png
+	c.Flags.SVG = svg
+	return c
+}
+
+func TestFromV3CallingCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		idd  models.IddV3
+		want []string
+	}{
+		{"one suffix", models.IddV3{Root: "+2", Suffixes: []string{"34"}}, []string{"234"}},
+		{"area code suffixes", models.IddV3{Root: "+1", Suffixes: []string{"201", "202", "203"}}, []string{"1"}},
+		{"root only", models.IddV3{Root: "+7"}, []string{"7"}},
+		{"none", models.IddV3{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fromV3(models.CountryDataV3{Idd: tt.idd}).CallingCodes
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CallingCodes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCountriesV2(t *testing.T) {
+	payload := `[{"name": "Ghana", "capital": "Accra", "alpha3Code": "GHA", "callingCodes": ["233"]}]`
+	got, err := decodeCountries(strings.NewReader(payload), FormatV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "Ghana" || got[0].Alpha3Code != "GHA" || got[0].CallingCodes[0] != "233" {
+		t.Errorf("decodeCountries = %+v", got)
+	}
+}
+
+func TestDecodeCountriesErrors(t *testing.T) {
+	if _, err := decodeCountries(strings.NewReader(`[]`), "v4"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unknown format: err = %v, want %v", err, ErrUnknownFormat)
+	}
+	if _, err := decodeCountries(strings.NewReader(`{"status": 404}`), FormatV31); err == nil {
+		t.Error("an object payload decoded without error")
+	}
+}
+
+func TestMergeDetails(t *testing.T) {
+	countries := []models.CountryData{
+		{Name: "Ghana", Alpha3Code: "GHA"},
+		{Name: "Togo", Alpha3Code: "TGO"},
+	}
+	details := []models.CountryData{
+		{Alpha3Code: "gha", Subregion: "Western Africa", Borders: []string{"TGO"}, Timezones: []string{"UTC"}},
+		{Alpha3Code: "", Subregion: "ignored"},
+	}
+	mergeDetails(countries, details)
+	if countries[0].Subregion != "Western Africa" || !reflect.DeepEqual(countries[0].Borders, []string{"TGO"}) {
+		t.Errorf("Ghana = %+v", countries[0])
+	}
+	if countries[1].Subregion != "" {
+		t.Errorf("Togo = %+v, want no details", countries[1])
+	}
+}
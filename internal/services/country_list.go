@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// function to list the countries matching a filter, in its sort order
+func (c *CountryService) ListCountries(filter db.CountryFilter) ([]db.Country, error) {
+	ctx := context.Background()
+	countries, err := c.q.ListCountries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("could not list countries: %w", err)
+	}
+	return countries, nil
+}
+
+// function to count the countries matching a filter
+func (c *CountryService) CountCountries(filter db.CountryFilter) (int64, error) {
+	ctx := context.Background()
+	total, err := c.q.CountCountries(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("could not count countries: %w", err)
+	}
+	return total, nil
+}
+
+// ListCountriesAsOf is ListCountries over the countries as they were at a
+// point in time. Those come from country_history, which the query builder
+// does not cover, so the filter is applied in memory.
+func (c *CountryService) ListCountriesAsOf(asOf time.Time, filter db.CountryFilter) ([]db.Country, error) {
+	countries, err := c.GetAllCountriesAsOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var currencyIDs, languageIDs map[int64]bool
+	if filter.Currency != "" {
+		if currencyIDs, err = c.CountryIDsWithCurrency(filter.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Language != "" {
+		if languageIDs, err = c.CountryIDsWithLanguage(filter.Language); err != nil {
+			return nil, err
+		}
+	}
+	filtered := []db.Country{}
+	for _, country := range countries {
+		if filter.Region != "" && !(country.Region.Valid && strings.EqualFold(country.Region.String, filter.Region)) {
+			continue
+		}
+		if filter.Subregion != "" && !(country.Subregion.Valid && strings.EqualFold(country.Subregion.String, filter.Subregion)) {
+			continue
+		}
+		if filter.Currency != "" && !currencyIDs[country.ID] &&
+			!(country.CurrencyCode.Valid && strings.EqualFold(country.CurrencyCode.String, filter.Currency)) {
+			continue
+		}
+		if filter.Language != "" && !languageIDs[country.ID] {
+			continue
+		}
+		if filter.MinReportedGdp != nil && !(country.ReportedGdp.Valid && parseNullStringFloat(country.ReportedGdp) >= *filter.MinReportedGdp) {
+			continue
+		}
+		if filter.MaxReportedGdp != nil && !(country.ReportedGdp.Valid && parseNullStringFloat(country.ReportedGdp) <= *filter.MaxReportedGdp) {
+			continue
+		}
+		filtered = append(filtered, country)
+	}
+	sortCountries(filtered, filter.Sort)
+
+	if filter.Offset > 0 {
+		filtered = filtered[min(filter.Offset, len(filtered)):]
+	}
+	if filter.Limit > 0 && len(filtered) > filter.Limit {
+		filtered = filtered[:filter.Limit]
+	}
+	return filtered, nil
+}
+
+// sortCountries mirrors the ORDER BY of db.CountryFilter in memory
+func sortCountries(countries []db.Country, order string) {
+	sort.SliceStable(countries, func(i, j int) bool {
+		a, b := countries[i], countries[j]
+		switch order {
+		case db.SortGDPDesc, db.SortGDPAsc:
+			if a.EstimatedGdp.Valid != b.EstimatedGdp.Valid {
+				// NULL sorts lowest, as in MySQL
+				return a.EstimatedGdp.Valid == (order == db.SortGDPDesc)
+			}
+			x, y := parseNullStringFloat(a.EstimatedGdp), parseNullStringFloat(b.EstimatedGdp)
+			if x != y {
+				if order == db.SortGDPDesc {
+					return x > y
+				}
+				return x < y
+			}
+		case db.SortReportedGDPDesc, db.SortReportedGDPAsc:
+			if a.ReportedGdp.Valid != b.ReportedGdp.Valid {
+				return a.ReportedGdp.Valid
+			}
+			x, y := parseNullStringFloat(a.ReportedGdp), parseNullStringFloat(b.ReportedGdp)
+			if x != y {
+				if order == db.SortReportedGDPDesc {
+					return x > y
+				}
+				return x < y
+			}
+		}
+		return a.ID < b.ID
+	})
+}
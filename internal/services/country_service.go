@@ -3,24 +3,175 @@ package internal
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	db "github.com/franzego/stage02/db/sqlc"
 	"github.com/franzego/stage02/models"
 )
 
 type CountryService struct {
-	q           *db.Queries
-	externalapi *ExternalApi
+	conn          *sql.DB
+	q             *db.Queries
+	sources       map[string]Sources
+	defaultSource string
+	policy        RefreshPolicy
+	gdp           GDPEstimator
+	importDir     string
+	rules         ValidationRules
+	flags         *FlagMirror
+	cursorSecret  []byte
+	cursorOnce    sync.Once
 }
 
-func NewCountryService(queries *db.Queries) *CountryService {
+// RefreshPolicy controls how a refresh writes to the database
+type RefreshPolicy struct {
+	// BatchSize is the number of rows per multi-row upsert
+	BatchSize int
+	// MaxBadRows is how many rows may fail before the whole refresh is
+	// rolled back; 0 means all-or-nothing, and negative values count as 0
+	MaxBadRows int
+	// MaxRemovalFraction is the largest share of existing countries a
+	// reconcile may remove before the refresh is aborted
+	MaxRemovalFraction float64
+	// RatesMaxAge is how old stored exchange rates may be for a targeted
+	// refresh to reuse them instead of calling the rates source
+	RatesMaxAge time.Duration
+}
+
+var DefaultRefreshPolicy = RefreshPolicy{
+	BatchSize:          50,
+	MaxBadRows:         0,
+	MaxRemovalFraction: 0.1,
+	RatesMaxAge:        time.Hour,
+}
+
+// Reconcile modes for countries no longer returned upstream
+const (
+	ReconcileNone   = ""
+	ReconcileDelete = "delete"
+	ReconcileMark   = "mark"
+)
+
+var (
+	// ErrTooManyBadRows aborts a refresh whose failures exceed MaxBadRows
+	ErrTooManyBadRows = errors.New("too many countries failed to save")
+	// ErrUnknownReconcile is returned for an unsupported reconcile mode
+	ErrUnknownReconcile = errors.New("unknown reconcile mode")
+	// ErrRemovalThreshold aborts a reconcile that would remove too much
+	ErrRemovalThreshold = errors.New("reconcile would remove too many countries")
+	// ErrInvalidScope is returned for a targeted refresh that asks for both
+	// a name and a region, or for a reconcile
+	ErrInvalidScope = errors.New("invalid refresh scope")
+	// ErrNoCountriesMatched is returned when a targeted refresh matches
+	// nothing upstream
+	ErrNoCountriesMatched = errors.New("no countries matched the refresh scope")
+)
+
+// NewCountryService registers countries and rates as the live source, which
+// is also the default until SetDefaultSource says otherwise
+func NewCountryService(conn *sql.DB, queries *db.Queries, countries CountrySource, rates RateSource) *CountryService {
 	return &CountryService{
-		q:           queries,
-		externalapi: NewExternalService(),
+		conn: conn,
+		q:    queries,
+		sources: map[string]Sources{
+			SourceLive: {Countries: countries, Rates: rates},
+		},
+		defaultSource: SourceLive,
+		policy:        DefaultRefreshPolicy,
+		gdp:           NewRandomEstimator(0),
+		importDir:     "data",
+		rules:         DefaultValidationRules,
+	}
+}
+
+// ValidateReconcile checks a reconcile mode name
+func ValidateReconcile(mode string) error {
+	switch mode {
+	case ReconcileNone, ReconcileDelete, ReconcileMark:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownReconcile, mode)
+}
+
+// SetRefreshPolicy overrides the batch size and bad row tolerance
+func (c *CountryService) SetRefreshPolicy(policy RefreshPolicy) {
+	if policy.BatchSize < 1 {
+		policy.BatchSize = DefaultRefreshPolicy.BatchSize
+	}
+	if policy.MaxBadRows < 0 {
+		policy.MaxBadRows = DefaultRefreshPolicy.MaxBadRows
 	}
+	c.policy = policy
+}
+
+// SetGDPEstimator picks how estimated_gdp is computed on refresh
+func (c *CountryService) SetGDPEstimator(estimator GDPEstimator) {
+	c.gdp = estimator
+}
+
+// RegisterSource makes a named pair of providers available to refreshes
+func (c *CountryService) RegisterSource(name string, countries CountrySource, rates RateSource) {
+	c.sources[name] = Sources{Countries: countries, Rates: rates}
+}
+
+// SetDefaultSource picks the source used when a refresh does not ask for one
+func (c *CountryService) SetDefaultSource(name string) error {
+	if _, ok := c.sources[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownSource, name)
+	}
+	c.defaultSource = name
+	return nil
+}
+
+// ResolveSource maps an empty name to the default and checks it exists
+func (c *CountryService) ResolveSource(name string) (string, error) {
+	if name == "" {
+		return c.defaultSource, nil
+	}
+	if _, ok := c.sources[name]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownSource, name)
+	}
+	return name, nil
+}
+
+// UpstreamStatus collects circuit breaker state from every registered source
+func (c *CountryService) UpstreamStatus() []BreakerSnapshot {
+	// the same breaker can be reachable through several sources, e.g. the
+	// er-api client is both a live source and a failover provider
+	seen := map[string]bool{}
+	var snapshots []BreakerSnapshot
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		src := c.sources[name]
+		for _, p := range []any{src.Countries, src.Rates} {
+			r, ok := p.(breakerReporter)
+			if !ok {
+				continue
+			}
+			for _, b := range r.Breakers() {
+				if !seen[b.Name] {
+					seen[b.Name] = true
+					snapshots = append(snapshots, b)
+				}
+			}
+		}
+	}
+	if c.flags != nil {
+		snapshots = append(snapshots, c.flags.Breakers()...)
+	}
+	return snapshots
 }
 
 // function to get totalcount
@@ -43,90 +194,642 @@ func (c *CountryService) GetRefreshTime() (sql.NullTime, error) {
 	return t, nil
 }
 
-// function to get all countries
-func (c *CountryService) GetAllCountries() ([]db.Country, error) {
+// function to get the rate provider used by the last successful refresh
+func (c *CountryService) GetLastRateProvider() (sql.NullString, error) {
 	ctx := context.Background()
-	countries, err := c.q.GetAllCountries(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("external data source unavailable: %w", err)
+	provider, err := c.q.GetLastRateProvider(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return sql.NullString{}, fmt.Errorf("there was a problem getting the rate provider: %w", err)
 	}
-	return countries, nil
+	return provider, nil
+}
+
+// RateProviders lists the rate providers of the default source in the
+// order they are tried
+func (c *CountryService) RateProviders() []string {
+	if f, ok := c.sources[c.defaultSource].Rates.(*RateFailover); ok {
+		return f.Providers()
+	}
+	return nil
 }
 
-// function to get countries by name
+// function to get countries by name or ISO 3166-1 code
 func (c *CountryService) GetCountryByName(name string) (db.Country, error) {
 	ctx := context.Background()
-	country, err := c.q.GetCountryByName(ctx, name)
+	country, err := findCountry(ctx, c.q, name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return db.Country{}, err
 		}
+		return db.Country{}, fmt.Errorf("could not get country: %w", err)
 	}
 	return country, nil
 
 }
 
+// function to get a country and its mirrored flag, by name or ISO code. The
+// flag is reported missing when mirroring is off or has not reached it yet.
+func (c *CountryService) GetCountryFlag(name string) (db.Country, FlagFile, bool, error) {
+	ctx := context.Background()
+	country, err := findCountry(ctx, c.q, name)
+	if err != nil {
+		return db.Country{}, FlagFile{}, false, lookupError(err)
+	}
+	if c.flags == nil || !country.FlagUrl.Valid {
+		return country, FlagFile{}, false, nil
+	}
+	flag, ok, err := c.flags.Lookup(ctx, country.FlagUrl.String)
+	if err != nil {
+		return db.Country{}, FlagFile{}, false, err
+	}
+	return country, flag, ok, nil
+}
+
+// findCountry looks a country up by name, then by alpha-2, alpha-3 or
+// numeric code when the key has the shape of one. Names win, so a code
+// never shadows a country that is called that.
+func findCountry(ctx context.Context, q *db.Queries, key string) (db.Country, error) {
+	country, err := q.GetCountryByName(ctx, key)
+	if err != sql.ErrNoRows || !isISOCode(key) {
+		return country, err
+	}
+	return q.GetCountryByCode(ctx, sql.NullString{String: strings.ToUpper(key), Valid: true})
+}
+
+// isISOCode reports whether key could be an ISO 3166-1 code
+func isISOCode(key string) bool {
+	return alpha2Pattern.MatchString(key) || alpha3Pattern.MatchString(key) || numericPattern.MatchString(key)
+}
+
+// CountryName resolves an ISO code to the stored country's name. Anything
+// else, including unknown codes, is returned unchanged.
+func (c *CountryService) CountryName(key string) (string, error) {
+	if !isISOCode(key) {
+		return key, nil
+	}
+	country, err := findCountry(context.Background(), c.q, key)
+	if err == sql.ErrNoRows {
+		return key, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not resolve country: %w", err)
+	}
+	return country.Name, nil
+}
+
+// storedCurrencies loads the currency codes of every stored country by id,
+// primary first, as JSON lists
+func storedCurrencies(ctx context.Context, qtx *db.Queries) (map[int64]sql.NullString, error) {
+	rows, err := qtx.ListCurrencyCodesByCountry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load country currencies: %w", err)
+	}
+	codes := make(map[int64][]string)
+	for _, row := range rows {
+		codes[row.CountryID] = append(codes[row.CountryID], row.CurrencyCode)
+	}
+	currencies := make(map[int64]sql.NullString, len(codes))
+	for id, list := range codes {
+		currencies[id] = jsonList(list)
+	}
+	return currencies, nil
+}
+
+// function to get every currency of a country, primary first
+func (c *CountryService) GetCountryCurrencies(countryID int64) ([]db.Currency, error) {
+	ctx := context.Background()
+	currencies, err := c.q.ListCountryCurrencies(ctx, countryID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get country currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+// function to get the ids of countries that use a currency
+func (c *CountryService) CountryIDsWithCurrency(code string) (map[int64]bool, error) {
+	ctx := context.Background()
+	ids, err := c.q.ListCountryIDsByCurrency(ctx, strings.ToUpper(code))
+	if err != nil {
+		return nil, fmt.Errorf("could not get countries by currency: %w", err)
+	}
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
 // function to delete countries by name
 func (c *CountryService) DeleteCountryByName(name string) error {
 	// check if it exists in db
 	ctx := context.Background()
-	country, err := c.q.GetCountryByName(ctx, name)
+	country, err := findCountry(ctx, c.q, name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return err
 		}
 		return err
 	}
-	// if there is no error, and it exists, we then delete
-	if err = c.q.DeleteCountryByName(ctx, country.Name); err != nil {
+	// if there is no error, and it exists, we then delete and end its
+	// history so time-travel queries stop returning it from now on
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := c.q.WithTx(tx)
+	if err = qtx.DeleteCountryByName(ctx, country.Name); err != nil {
 		return err
 	}
+	if err = qtx.CloseCountryHistory(ctx, db.CloseCountryHistoryParams{
+		ValidTo:   sql.NullTime{Time: time.Now().UTC().Truncate(time.Second), Valid: true},
+		CountryID: sql.NullInt64{Int64: country.ID, Valid: true},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RefreshOptions tunes a single run of RefreshCountries
+type RefreshOptions struct {
+	// Source names the registered providers to read from; empty means default
+	Source string
+	// JobID, when set, is used to persist the change report
+	JobID int64
+	// Reconcile deletes or marks stale the countries missing upstream
+	Reconcile string
+	// Name or Region limit the refresh to the matching countries. A
+	// targeted refresh never reconciles or reports removals.
+	Name   string
+	Region string
+	// Progress, when set, is called after each batch is written
+	Progress func(processed, failed int)
+}
+
+// RefreshResult summarises what a refresh did
+type RefreshResult struct {
+	Processed int
+	Failed    int
+	// Quarantined counts the upstream records that failed validation
+	Quarantined int
+	// RateProvider names where the exchange rates came from
+	RateProvider string
+	// Failures lists the rows that could not be saved
+	Failures []RowFailure
+	// Changes is the diff against the table before the refresh
+	Changes ChangeReport
+}
+
+// RowFailure records why a single country could not be saved
+type RowFailure struct {
+	Name string
+	Err  error
+}
+
+func (f RowFailure) String() string {
+	return fmt.Sprintf("%s: %v", f.Name, f.Err)
+}
+
+// tooManyBadRows aborts a refresh whose failures exceed MaxBadRows, naming
+// the last failure when there is one
+func (c *CountryService) tooManyBadRows(result RefreshResult) error {
+	if n := len(result.Failures); n > 0 {
+		return fmt.Errorf("%w: %d failed, %d tolerated (last: %s)",
+			ErrTooManyBadRows, result.Failed, c.policy.MaxBadRows, result.Failures[n-1])
+	}
+	return fmt.Errorf("%w: %d failed, %d tolerated", ErrTooManyBadRows, result.Failed, c.policy.MaxBadRows)
+}
+
+// Targeted reports whether the refresh is limited to a name or region
+func (o RefreshOptions) Targeted() bool {
+	return o.Name != "" || o.Region != ""
+}
+
+// Scope describes a targeted refresh as stored on its job, e.g.
+// "region:Africa"; empty for a full refresh
+func (o RefreshOptions) Scope() string {
+	switch {
+	case o.Name != "":
+		return "name:" + o.Name
+	case o.Region != "":
+		return "region:" + o.Region
+	}
+	return ""
+}
+
+// ValidateScope checks that a targeted refresh asks for one thing only
+func ValidateScope(opts RefreshOptions) error {
+	if opts.Name != "" && opts.Region != "" {
+		return fmt.Errorf("%w: name and region cannot be combined", ErrInvalidScope)
+	}
+	if opts.Targeted() && opts.Reconcile != ReconcileNone {
+		return fmt.Errorf("%w: reconcile needs a full refresh", ErrInvalidScope)
+	}
 	return nil
 }
 
 // function to refresh countries
-func (c *CountryService) RefreshCountries() error {
-	country, err := c.externalapi.FetchAllCountries()
+// The whole refresh runs in one transaction: rows are written in batches and
+// a batch that fails is retried row by row so bad rows can be counted
+// against the policy's tolerance. Exceeding it rolls everything back.
+func (c *CountryService) RefreshCountries(ctx context.Context, opts RefreshOptions) (RefreshResult, error) {
+	var result RefreshResult
+	name, err := c.ResolveSource(opts.Source)
 	if err != nil {
-		return fmt.Errorf("external data source unavailable: %w", err)
+		return result, err
+	}
+	if err := ValidateReconcile(opts.Reconcile); err != nil {
+		return result, err
 	}
+	if err := ValidateScope(opts); err != nil {
+		return result, err
+	}
+	source := c.sources[name]
 
-	rates, err := c.externalapi.FetchExchangeRate()
+	var country []models.CountryData
+	switch {
+	case opts.Name != "":
+		country, err = source.Countries.FetchCountriesByName(ctx, opts.Name)
+	case opts.Region != "":
+		country, err = source.Countries.FetchCountriesByRegion(ctx, opts.Region)
+	default:
+		country, err = source.Countries.FetchAllCountries(ctx)
+	}
 	if err != nil {
-		return fmt.Errorf("external rates source unavailable: %w", err)
+		return result, fmt.Errorf("external data source unavailable: %w", err)
+	}
+	if opts.Targeted() && len(country) == 0 {
+		return result, fmt.Errorf("%w: %s", ErrNoCountriesMatched, opts.Scope())
 	}
+	country, held, err := c.screenCountries(ctx, country)
+	if err != nil {
+		return result, err
+	}
+	result.Quarantined = len(held)
 
-	ctx := context.Background()
+	// a targeted refresh reuses recent stored rates rather than refetching
+	var rates models.RateSet
+	cached := false
+	if opts.Targeted() {
+		if rates, cached, err = c.cachedRates(ctx); err != nil {
+			return result, err
+		}
+	}
+	if !cached {
+		if rates, err = source.Rates.FetchExchangeRate(ctx); err != nil {
+			return result, fmt.Errorf("external rates source unavailable: %w", err)
+		}
+	}
+	result.RateProvider = rates.Provider
+
+	processed := make([]models.ProcessedCountry, 0, len(country))
+	rows := make([]db.UpsertCountryParams, 0, len(country))
 	for _, count := range country {
-		processed := c.processCountry(count, rates)
-		if err := c.upsertCountry(ctx, processed); err != nil {
-			fmt.Printf("Failed to upsert country %s: %v\n", processed.Name, err)
-			continue
+		p := c.processCountry(count, rates.Rates)
+		processed = append(processed, p)
+		rows = append(rows, toUpsertParams(p))
+	}
+
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("could not start refresh transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := c.q.WithTx(tx)
+
+	previous, err := qtx.GetAllCountries(ctx)
+	if err != nil {
+		return result, fmt.Errorf("could not load current countries: %w", err)
+	}
+	languages := languageSets{after: processedLanguages(processed)}
+	if languages.before, err = storedLanguages(ctx, qtx); err != nil {
+		return result, err
+	}
+	renames, conflicts := planRenames(previous, rows)
+	for _, f := range conflicts {
+		log.Printf("Failed to upsert country %s: %v", f.Name, f.Err)
+		result.Failed++
+		result.Failures = append(result.Failures, f)
+	}
+	if result.Failed > c.policy.MaxBadRows {
+		return result, c.tooManyBadRows(result)
+	}
+	if opts.Targeted() {
+		// countries outside the scope were not asked for, so they must not
+		// look removed
+		previous = inScope(previous, rows, heldNames(held))
+	}
+
+	if !cached {
+		if err := c.storeRates(ctx, qtx, rates); err != nil {
+			return result, err
+		}
+	}
+
+	if err := renameCountries(ctx, qtx, renames); err != nil {
+		return result, err
+	}
+	writable := withoutFailures(rows, conflicts)
+	for start := 0; start < len(writable); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(writable))
+		if err := c.writeBatch(ctx, tx, qtx, writable[start:end], &result); err != nil {
+			return result, err
+		}
+		if opts.Progress != nil {
+			opts.Progress(result.Processed, result.Failed)
+		}
+	}
+
+	failed := failedNames(result.Failures)
+	ids, err := countryIDs(ctx, qtx)
+	if err != nil {
+		return result, err
+	}
+	if err := c.writeCurrencies(ctx, qtx, processed, failed, ids); err != nil {
+		return result, err
+	}
+	if err := c.writeLanguages(ctx, qtx, processed, failed, ids); err != nil {
+		return result, err
+	}
+
+	if err := writeQuarantine(ctx, qtx, opts.JobID, held); err != nil {
+		return result, err
+	}
+
+	result.Changes = diffCountries(previous, rows, failed, heldNames(held), languages)
+	if opts.Reconcile != ReconcileNone {
+		if err := c.reconcile(ctx, qtx, opts.Reconcile, len(previous), result.Changes); err != nil {
+			return result, err
 		}
 	}
+	if err := c.writeHistory(ctx, qtx, result.Changes, opts.Reconcile); err != nil {
+		return result, err
+	}
+	if opts.JobID != 0 {
+		changes := changeRows(opts.JobID, result.Changes)
+		for start := 0; start < len(changes); start += c.policy.BatchSize {
+			end := min(start+c.policy.BatchSize, len(changes))
+			if err := qtx.CreateRefreshChanges(ctx, changes[start:end]); err != nil {
+				return result, fmt.Errorf("could not save change report: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("could not commit refresh: %w", err)
+	}
+
 	imageService := NewImageService(c.q)
 	if err := imageService.GenerateSummaryImage(ctx); err != nil {
 		// Log error but don't fail the whole refresh
-		fmt.Printf("Failed to generate image: %v\n", err)
+		log.Printf("Failed to generate image: %v", err)
+	}
+	if c.flags != nil {
+		// flags are a convenience copy, so failures are only logged
+		var urls []string
+		for _, row := range rows {
+			if row.FlagUrl.Valid && !failed[row.Name] {
+				urls = append(urls, row.FlagUrl.String)
+			}
+		}
+		mirrored := c.flags.Mirror(ctx, urls)
+		log.Printf("Flags mirrored: %d downloaded, %d not modified, %d failed",
+			mirrored.Downloaded, mirrored.NotModified, mirrored.Failed)
 	}
 
+	return result, nil
+}
+
+// writeBatch upserts rows in one statement, falling back to one statement
+// per row when the batch is rejected. Savepoints keep a failed statement
+// from poisoning the rest of the transaction.
+func (c *CountryService) writeBatch(ctx context.Context, tx *sql.Tx, qtx *db.Queries, rows []db.UpsertCountryParams, result *RefreshResult) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT refresh_batch"); err != nil {
+		return fmt.Errorf("could not create savepoint: %w", err)
+	}
+	if err := qtx.UpsertCountries(ctx, rows); err == nil {
+		result.Processed += len(rows)
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT refresh_batch"); err != nil {
+		return fmt.Errorf("could not roll back batch: %w", err)
+	}
+
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT refresh_row"); err != nil {
+			return fmt.Errorf("could not create savepoint: %w", err)
+		}
+		if err := qtx.UpsertCountry(ctx, row); err != nil {
+			log.Printf("Failed to upsert country %s: %v", row.Name, err)
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT refresh_row"); rbErr != nil {
+				return fmt.Errorf("could not roll back row: %w", rbErr)
+			}
+			result.Failed++
+			result.Failures = append(result.Failures, RowFailure{Name: row.Name, Err: err})
+			if result.Failed > c.policy.MaxBadRows {
+				return c.tooManyBadRows(*result)
+			}
+			continue
+		}
+		result.Processed++
+	}
 	return nil
 }
 
+// reconcile deletes or marks stale the countries the diff found missing
+// upstream, refusing when that is more than the policy allows
+func (c *CountryService) reconcile(ctx context.Context, qtx *db.Queries, mode string, existing int, report ChangeReport) error {
+	ids := report.RemovedIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	if fraction := float64(len(ids)) / float64(existing); fraction > c.policy.MaxRemovalFraction {
+		return fmt.Errorf("%w: %d of %d (%.0f%%, limit %.0f%%)",
+			ErrRemovalThreshold, len(ids), existing, fraction*100, c.policy.MaxRemovalFraction*100)
+	}
+
+	var err error
+	if mode == ReconcileDelete {
+		err = qtx.DeleteCountriesByIDs(ctx, ids)
+	} else {
+		err = qtx.MarkCountriesStale(ctx, ids)
+	}
+	if err != nil {
+		return fmt.Errorf("could not reconcile removed countries: %w", err)
+	}
+	return nil
+}
+
+// countryIDs maps lower-cased country names to their row ids
+func countryIDs(ctx context.Context, qtx *db.Queries) (map[string]int64, error) {
+	idRows, err := qtx.ListCountryIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load country ids: %w", err)
+	}
+	ids := make(map[string]int64, len(idRows))
+	for _, r := range idRows {
+		ids[strings.ToLower(r.Name)] = r.ID
+	}
+	return ids, nil
+}
+
+// writeCurrencies stores every currency of the saved countries and links
+// them through country_currencies, replacing the previous links
+func (c *CountryService) writeCurrencies(ctx context.Context, qtx *db.Queries, processed []models.ProcessedCountry, failed map[string]bool, ids map[string]int64) error {
+	currencies, countryIDs, links := currencyRows(processed, failed, ids)
+	for start := 0; start < len(currencies); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(currencies))
+		if err := qtx.UpsertCurrencies(ctx, currencies[start:end]); err != nil {
+			return fmt.Errorf("could not save currencies: %w", err)
+		}
+	}
+
+	for start := 0; start < len(countryIDs); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(countryIDs))
+		var batch []db.CreateCountryCurrencyParams
+		for _, id := range countryIDs[start:end] {
+			batch = append(batch, links[id]...)
+		}
+		if err := qtx.ReplaceCountryCurrencies(ctx, countryIDs[start:end], batch); err != nil {
+			return fmt.Errorf("could not save country currencies: %w", err)
+		}
+	}
+	return nil
+}
+
+// currencyRows builds the currency rows, sorted by code, and the links of
+// each saved country, in the order the countries were processed
+func currencyRows(processed []models.ProcessedCountry, failed map[string]bool, ids map[string]int64) ([]db.UpsertCurrencyParams, []int64, map[int64][]db.CreateCountryCurrencyParams) {
+	currencies := map[string]db.UpsertCurrencyParams{}
+	var countryIDs []int64
+	links := map[int64][]db.CreateCountryCurrencyParams{}
+	for _, p := range processed {
+		id, ok := ids[strings.ToLower(p.Name)]
+		if !ok || failed[p.Name] {
+			continue
+		}
+		countryIDs = append(countryIDs, id)
+		for i, cur := range p.Currencies {
+			currencies[cur.Code] = db.UpsertCurrencyParams{
+				Code:   cur.Code,
+				Name:   sql.NullString{String: cur.Name, Valid: cur.Name != ""},
+				Symbol: sql.NullString{String: cur.SymbolUrl, Valid: cur.SymbolUrl != ""},
+			}
+			links[id] = append(links[id], db.CreateCountryCurrencyParams{
+				CountryID:    id,
+				CurrencyCode: cur.Code,
+				Position:     int32(i),
+			})
+		}
+	}
+
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	rows := make([]db.UpsertCurrencyParams, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, currencies[code])
+	}
+	return rows, countryIDs, links
+}
+
+// inScope keeps the previous rows that a targeted refresh wrote again,
+// renamed or held in quarantine
+func inScope(previous []db.Country, rows []db.UpsertCountryParams, held []string) []db.Country {
+	names := make(map[string]bool, len(rows)+len(held))
+	codes := newCodeIndex(previous)
+	for _, row := range rows {
+		names[strings.ToLower(row.Name)] = true
+		if o, err := codes.owner(row); err == nil && o != nil {
+			names[strings.ToLower(o.Name)] = true
+		}
+	}
+	for _, name := range held {
+		names[strings.ToLower(name)] = true
+	}
+	kept := make([]db.Country, 0, len(rows))
+	for _, country := range previous {
+		if names[strings.ToLower(country.Name)] {
+			kept = append(kept, country)
+		}
+	}
+	return kept
+}
+
+// failedNames indexes the names of rows that failed to save
+func failedNames(failures []RowFailure) map[string]bool {
+	failed := make(map[string]bool, len(failures))
+	for _, f := range failures {
+		failed[f.Name] = true
+	}
+	return failed
+}
+
 // function that processes data
 // processCountry extracts currency, calculates GDP, and prepares data
 func (c *CountryService) processCountry(country models.CountryData, exchangeRates map[string]float64) models.ProcessedCountry {
 	processed := models.ProcessedCountry{
-		Name:       country.Name,
-		Capital:    country.Capital,
-		Region:     country.Region,
-		Population: country.Population,
-		FlagURL:    country.Flag,
+		Name:        country.Name,
+		Capital:     country.Capital,
+		Region:      country.Region,
+		Population:  country.Population,
+		FlagURL:     country.Flag,
+		Alpha2Code:  strings.ToUpper(strings.TrimSpace(country.Alpha2Code)),
+		Alpha3Code:  strings.ToUpper(strings.TrimSpace(country.Alpha3Code)),
+		NumericCode: strings.TrimSpace(country.NumericCode),
+		Subregion:   country.Subregion,
+		Area:        country.Area,
+		Timezones:   country.Timezones,
+		Borders:     country.Borders,
+	}
+	if len(country.Latlng) == 2 {
+		processed.Latitude = &country.Latlng[0]
+		processed.Longitude = &country.Latlng[1]
+	}
+	if len(country.CapitalLatlng) == 2 {
+		processed.CapitalLatitude = &country.CapitalLatlng[0]
+		processed.CapitalLongitude = &country.CapitalLatlng[1]
+	}
+	for _, code := range country.CallingCodes {
+		if code = strings.TrimPrefix(strings.TrimSpace(code), "+"); code != "" {
+			processed.CallingCodes = append(processed.CallingCodes, code)
+		}
+	}
+	// languages are keyed by their three-letter code
+	seenLang := map[string]bool{}
+	for _, lang := range country.Languages {
+		code := strings.ToLower(lang.Iso6392)
+		if code == "" || len(code) > 10 || seenLang[code] {
+			continue
+		}
+		seenLang[code] = true
+		lang.Iso6392 = code
+		lang.Iso6391 = strings.ToLower(lang.Iso6391)
+		if len(lang.Iso6391) != 2 {
+			lang.Iso6391 = ""
+		}
+		processed.Languages = append(processed.Languages, lang)
+	}
+
+	// Keep every currency with a code; the first one stays the primary
+	// currency used for the exchange rate and GDP estimate
+	seen := map[string]bool{}
+	for _, cur := range country.Currencies {
+		if cur.Code == "" || seen[cur.Code] {
+			continue
+		}
+		seen[cur.Code] = true
+		processed.Currencies = append(processed.Currencies, cur)
 	}
 
 	// Extract first currency code
-	if len(country.Currencies) > 0 && country.Currencies[0].Code != "" {
-		currencyCode := country.Currencies[0].Code
+	if len(processed.Currencies) > 0 {
+		currencyCode := processed.Currencies[0].Code
 		processed.CurrencyCode = &currencyCode
 
 		// Match with exchange rate
@@ -134,8 +837,10 @@ func (c *CountryService) processCountry(country models.CountryData, exchangeRate
 			processed.ExchangeRate = &rate
 
 			// Calculate estimated GDP
-			gdp := c.calculateGDP(country.Population, rate)
-			processed.EstimatedGDP = &gdp
+			estimate := c.gdp.Estimate(country.Name, country.Population, rate)
+			processed.EstimatedGDP = &estimate.Value
+			processed.GDPMultiplier = &estimate.Multiplier
+			processed.GDPStrategy = estimate.Strategy
 		} else {
 			// Currency not found in exchange rates
 			processed.ExchangeRate = nil
@@ -152,19 +857,12 @@ func (c *CountryService) processCountry(country models.CountryData, exchangeRate
 	return processed
 }
 
-// function to calculate gdp
-func (c *CountryService) calculateGDP(population int64, rate float64) float64 {
-	multiplier := rand.Float64()*1000 + 1000
-
-	gdp := (float64(population) * multiplier) / rate
-	return gdp
-}
-
-// function to insert into db
-func (c *CountryService) upsertCountry(ctx context.Context, country models.ProcessedCountry) error {
+// toUpsertParams converts a processed country into query parameters
+func toUpsertParams(country models.ProcessedCountry) db.UpsertCountryParams {
 	// Convert nullable fields to sql.Null types
 	var capital, region, currencyCode, flagURL sql.NullString
 	var exchangeRate, estimatedGDP sql.NullString
+	var gdpStrategy, gdpMultiplier sql.NullString
 
 	if country.Capital != "" {
 		capital = sql.NullString{String: country.Capital, Valid: true}
@@ -190,14 +888,71 @@ func (c *CountryService) upsertCountry(ctx context.Context, country models.Proce
 		estimatedGDP = sql.NullString{String: strconv.FormatFloat(*country.EstimatedGDP, 'f', 2, 64), Valid: true}
 	}
 
-	return c.q.UpsertCountry(ctx, db.UpsertCountryParams{
-		Name:         country.Name,
-		Capital:      capital,
-		Region:       region,
-		Population:   country.Population,
-		CurrencyCode: currencyCode,
-		ExchangeRate: exchangeRate,
-		EstimatedGdp: estimatedGDP,
-		FlagUrl:      flagURL,
-	})
+	if country.GDPMultiplier != nil {
+		gdpStrategy = sql.NullString{String: country.GDPStrategy, Valid: true}
+		gdpMultiplier = sql.NullString{String: strconv.FormatFloat(*country.GDPMultiplier, 'f', 4, 64), Valid: true}
+	}
+
+	return db.UpsertCountryParams{
+		Name:             country.Name,
+		Capital:          capital,
+		Region:           region,
+		Population:       country.Population,
+		CurrencyCode:     currencyCode,
+		ExchangeRate:     exchangeRate,
+		EstimatedGdp:     estimatedGDP,
+		FlagUrl:          flagURL,
+		GdpStrategy:      gdpStrategy,
+		GdpMultiplier:    gdpMultiplier,
+		Alpha2Code:       nullString(country.Alpha2Code),
+		Alpha3Code:       nullString(country.Alpha3Code),
+		NumericCode:      nullString(country.NumericCode),
+		Subregion:        nullString(country.Subregion),
+		Area:             nullFloat(country.Area, 2),
+		Latitude:         nullFloat(country.Latitude, 6),
+		Longitude:        nullFloat(country.Longitude, 6),
+		Timezones:        jsonList(country.Timezones),
+		CallingCodes:     jsonList(country.CallingCodes),
+		Borders:          jsonList(country.Borders),
+		CapitalLatitude:  nullFloat(country.CapitalLatitude, 6),
+		CapitalLongitude: nullFloat(country.CapitalLongitude, 6),
+	}
+}
+
+// nullString stores an empty string as NULL, so unknown codes do not
+// collide in the unique indexes
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullFloat formats a nullable decimal column
+func nullFloat(v *float64, prec int) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: strconv.FormatFloat(*v, 'f', prec, 64), Valid: true}
+}
+
+// jsonList stores a list as a JSON array, or NULL when it is empty
+func jsonList(values []string) sql.NullString {
+	if len(values) == 0 {
+		return sql.NullString{}
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(b), Valid: true}
+}
+
+// ParseJSONList reads a list stored by jsonList; NULL or bad JSON is empty
+func ParseJSONList(s sql.NullString) []string {
+	if !s.Valid {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(s.String), &values); err != nil {
+		return nil
+	}
+	return values
 }
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+	"github.com/franzego/stage02/models"
+)
+
+func TestSetRefreshPolicy(t *testing.T) {
+	c := NewCountryService(nil, nil, nil, nil)
+	c.SetRefreshPolicy(RefreshPolicy{BatchSize: 0, MaxBadRows: -3})
+	if c.policy.BatchSize != DefaultRefreshPolicy.BatchSize {
+		t.Errorf("BatchSize = %d, want %d", c.policy.BatchSize, DefaultRefreshPolicy.BatchSize)
+	}
+	if c.policy.MaxBadRows != 0 {
+		t.Errorf("MaxBadRows = %d, want 0", c.policy.MaxBadRows)
+	}
+}
+
+func TestTooManyBadRows(t *testing.T) {
+	c := NewCountryService(nil, nil, nil, nil)
+	tests := []struct {
+		name   string
+		result RefreshResult
+		want   string
+	}{
+		{
+			"names the last failure",
+			RefreshResult{Failed: 2, Failures: []RowFailure{
+				{Name: "Ghana", Err: errors.New("first")},
+				{Name: "Togo", Err: errors.New("second")},
+			}},
+			"too many countries failed to save: 2 failed, 0 tolerated (last: Togo: second)",
+		},
+		{
+			"without failures",
+			RefreshResult{Failed: 1},
+			"too many countries failed to save: 1 failed, 0 tolerated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.tooManyBadRows(tt.result)
+			if !errors.Is(err, ErrTooManyBadRows) || err.Error() != tt.want {
+				t.Errorf("tooManyBadRows = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInScope(t *testing.T) {
+	previous := []db.Country{
+		codedCountry(1, "Ghana", "GH", "GHA", "288"),
+		codedCountry(2, "Togo", "TG", "TGO", "768"),
+		codedCountry(3, "Japan", "JP", "JPN", "392"),
+		codedCountry(4, "Swaziland", "SZ", "SWZ", "748"),
+	}
+	// a refresh of Africa: Ghana came back, Togo was quarantined and
+	// Swaziland returned under its new name
+	rows := []db.UpsertCountryParams{
+		codedRow("ghana", "GH", "GHA", "288"),
+		codedRow("Eswatini", "SZ", "SWZ", "748"),
+	}
+	kept := inScope(previous, rows, []string{"Togo"})
+
+	var ids []int64
+	for _, country := range kept {
+		ids = append(ids, country.ID)
+	}
+	if want := []int64{1, 2, 4}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("inScope kept %v, want %v", ids, want)
+	}
+
+	report := diffCountries(kept, rows, nil, []string{"Togo"}, languageSets{})
+	if report.Removed != 0 {
+		t.Errorf("Removed = %d, out-of-scope countries must not be removed: %+v", report.Removed, report.Changes)
+	}
+}
+
+func TestProcessCountryCurrencies(t *testing.T) {
+	c := NewCountryService(nil, nil, nil, nil)
+	c.SetGDPEstimator(NewFixedEstimator(1000))
+	rates := map[string]float64{"XOF": 600, "EUR": 0.9}
+	cedi := models.Currency{Code: "GHS", Name: "Ghanaian cedi"}
+	franc := models.Currency{Code: "XOF", Name: "West African CFA franc"}
+	euro := models.Currency{Code: "EUR", Name: "Euro"}
+
+	tests := []struct {
+		name       string
+		currencies []models.Currency
+		want       []models.Currency
+		wantCode   string
+		wantRate   float64
+	}{
+		{"primary first", []models.Currency{franc, euro}, []models.Currency{franc, euro}, "XOF", 600},
+		{"codeless skipped", []models.Currency{{Name: "Krajinski dinar"}, euro}, []models.Currency{euro}, "EUR", 0.9},
+		{"duplicates skipped", []models.Currency{euro, franc, euro}, []models.Currency{euro, franc}, "EUR", 0.9},
+		{"primary without a rate", []models.Currency{cedi, euro}, []models.Currency{cedi, euro}, "GHS", 0},
+		{"none", []models.Currency{{Name: "no code"}}, nil, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := c.processCountry(models.CountryData{Name: "Testland", Population: 9000, Currencies: tt.currencies}, rates)
+			if !reflect.DeepEqual(p.Currencies, tt.want) {
+				t.Errorf("Currencies = %+v, want %+v", p.Currencies, tt.want)
+			}
+			if tt.wantCode == "" {
+				if p.CurrencyCode != nil || p.EstimatedGDP == nil || *p.EstimatedGDP != 0 {
+					t.Errorf("CurrencyCode, EstimatedGDP = %v, %v, want nil and 0", p.CurrencyCode, p.EstimatedGDP)
+				}
+				return
+			}
+			if p.CurrencyCode == nil || *p.CurrencyCode != tt.wantCode {
+				t.Fatalf("CurrencyCode = %v, want %s", p.CurrencyCode, tt.wantCode)
+			}
+			if tt.wantRate == 0 {
+				if p.ExchangeRate != nil || p.EstimatedGDP != nil {
+					t.Errorf("ExchangeRate, EstimatedGDP = %v, %v, want nil", p.ExchangeRate, p.EstimatedGDP)
+				}
+				return
+			}
+			if p.ExchangeRate == nil || *p.ExchangeRate != tt.wantRate {
+				t.Errorf("ExchangeRate = %v, want %v", p.ExchangeRate, tt.wantRate)
+			}
+			if want := 9000 * 1000 / tt.wantRate; p.EstimatedGDP == nil || *p.EstimatedGDP != want {
+				t.Errorf("EstimatedGDP = %v, want %v", p.EstimatedGDP, want)
+			}
+		})
+	}
+}
+
+func TestCurrencyRows(t *testing.T) {
+	euro := models.Currency{Code: "EUR", Name: "Euro", SymbolUrl: "€"}
+	franc := models.Currency{Code: "XOF", Name: "West African CFA franc"}
+	processed := []models.ProcessedCountry{
+		{Name: "Togo", Currencies: []models.Currency{franc, euro}},
+		{Name: "Ghana", Currencies: []models.Currency{{Code: "GHS"}}},
+		{Name: "France", Currencies: []models.Currency{euro}},
+		{Name: "Unsaved", Currencies: []models.Currency{{Code: "ZZZ"}}},
+		{Name: "Antarctica"},
+	}
+	ids := map[string]int64{"togo": 1, "ghana": 2, "france": 3, "antarctica": 4}
+
+	currencies, countryIDs, links := currencyRows(processed, map[string]bool{"Ghana": true}, ids)
+
+	wantCurrencies := []db.UpsertCurrencyParams{
+		{Code: "EUR", Name: nullString("Euro"), Symbol: nullString("€")},
+		{Code: "XOF", Name: nullString("West African CFA franc")},
+	}
+	if !reflect.DeepEqual(currencies, wantCurrencies) {
+		t.Errorf("currencies = %+v, want %+v", currencies, wantCurrencies)
+	}
+	// a saved country without currencies still has its links replaced
+	if want := []int64{1, 3, 4}; !reflect.DeepEqual(countryIDs, want) {
+		t.Errorf("countryIDs = %v, want %v", countryIDs, want)
+	}
+	wantLinks := map[int64][]db.CreateCountryCurrencyParams{
+		1: {{CountryID: 1, CurrencyCode: "XOF", Position: 0}, {CountryID: 1, CurrencyCode: "EUR", Position: 1}},
+		3: {{CountryID: 3, CurrencyCode: "EUR", Position: 0}},
+	}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("links = %+v, want %+v", links, wantLinks)
+	}
+}
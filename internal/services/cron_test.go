@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after string
+		want  string
+	}{
+		{"every quarter hour", "*/15 * * * *", "2024-09-02 10:07:00", "2024-09-02 10:15:00"},
+		{"strictly after", "@hourly", "2024-09-02 10:00:00", "2024-09-02 11:00:00"},
+		{"seconds are dropped", "* * * * *", "2024-09-02 10:00:59", "2024-09-02 10:01:00"},
+		{"daily rolls over the month", "0 0 * * *", "2024-01-31 23:59:30", "2024-02-01 00:00:00"},
+		{"list", "5,50 * * * *", "2024-09-02 10:06:00", "2024-09-02 10:50:00"},
+		{"weekday range", "0 9 * * 1-5", "2024-09-06 10:00:00", "2024-09-09 09:00:00"},
+		{"stepped range", "0 8-18/4 * * *", "2024-09-02 13:00:00", "2024-09-02 16:00:00"},
+		{"day of month only", "0 0 13 * *", "2024-09-01 00:00:00", "2024-09-13 00:00:00"},
+		{"day of week only", "0 0 * * 5", "2024-09-01 00:00:00", "2024-09-06 00:00:00"},
+		// a restricted day-of-month and day-of-week match either one
+		{"day of month or week", "0 0 13 * 1", "2024-09-10 00:00:00", "2024-09-13 00:00:00"},
+		{"day of week or month", "0 0 13 * 1", "2024-09-01 00:00:00", "2024-09-02 00:00:00"},
+		{"sunday as 0", "0 0 * * 0", "2024-09-01 00:00:00", "2024-09-08 00:00:00"},
+		{"sunday as 7", "0 0 * * 7", "2024-09-01 00:00:00", "2024-09-08 00:00:00"},
+		{"leap day", "0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"yearly", "@yearly", "2024-09-02 10:00:00", "2025-01-01 00:00:00"},
+		{"never fires", "0 0 31 2 *", "2024-01-01 00:00:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			got := s.Next(at(tt.after))
+			var want time.Time
+			if tt.want != "" {
+				want = at(tt.want)
+			}
+			if !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@fortnightly",
+	}
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestIntervalScheduleNext(t *testing.T) {
+	now := time.Date(2024, 9, 2, 10, 7, 30, 0, time.UTC)
+	s := IntervalSchedule{Every: 90 * time.Minute}
+	if got, want := s.Next(now), now.Add(90*time.Minute); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+	if got, want := s.String(), "@every 1h30m0s"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// DefaultPageLimit is the page size when a cursor is given without a limit
+const DefaultPageLimit = 50
+
+// ErrInvalidCursor is returned for a cursor that is malformed, was not
+// signed by us, or belongs to a listing with other filters or sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the signed content of a GET /countries cursor
+type pageCursor struct {
+	// Filter fingerprints the filters and sort the cursor was issued for
+	Filter string  `json:"f"`
+	Value  *string `json:"v,omitempty"`
+	ID     int64   `json:"id"`
+	Limit  int     `json:"l"`
+}
+
+// CountryPage is one page of a keyset-paginated listing
+type CountryPage struct {
+	Countries []db.Country
+	// Next is the cursor of the following page, empty on the last one
+	Next  string
+	Total int64
+}
+
+// SetCursorSecret sets the key cursors are signed with. Without one a
+// random key is used, so cursors stop working on restart and are not
+// shared between replicas.
+func (c *CountryService) SetCursorSecret(secret []byte) {
+	c.cursorSecret = secret
+}
+
+func (c *CountryService) cursorKey() []byte {
+	c.cursorOnce.Do(func() {
+		if len(c.cursorSecret) > 0 {
+			return
+		}
+		c.cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(c.cursorSecret); err != nil {
+			panic(fmt.Sprintf("could not generate cursor secret: %v", err))
+		}
+	})
+	return c.cursorSecret
+}
+
+// filterFingerprint identifies the filters and sort of a listing, so a
+// cursor cannot be replayed against a different one
+func filterFingerprint(f db.CountryFilter) string {
+	parts := []string{
+		strings.ToLower(f.Region), strings.ToLower(f.Subregion), strings.ToUpper(f.Currency),
+		strings.ToLower(strings.TrimSpace(f.Language)), f.Sort, floatParam(f.MinReportedGdp), floatParam(f.MaxReportedGdp),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func floatParam(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', -1, 64)
+}
+
+// encodeCursor signs a cursor as base64url(json) "." base64url(hmac)
+func (c *CountryService) encodeCursor(cur pageCursor) (string, error) {
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", fmt.Errorf("could not encode cursor: %w", err)
+	}
+	return c.signCursor(payload), nil
+}
+
+// signCursor appends the signature of payload to its encoding
+func (c *CountryService) signCursor(payload []byte) string {
+	mac := hmac.New(sha256.New, c.cursorKey())
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CountryService) decodeCursor(s string) (pageCursor, error) {
+	var cur pageCursor
+	data, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return cur, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	mac := hmac.New(sha256.New, c.cursorKey())
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cur); err != nil {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
+
+// ListCountriesPage returns one page of the countries matching filter,
+// continuing after cursor when it is not empty. Pages are keyed on the sort
+// value and id rather than an offset, so rows added or removed between
+// requests do not shift later pages.
+func (c *CountryService) ListCountriesPage(filter db.CountryFilter, cursor string) (CountryPage, error) {
+	fingerprint := filterFingerprint(filter)
+	if cursor != "" {
+		cur, err := c.decodeCursor(cursor)
+		if err != nil {
+			return CountryPage{}, err
+		}
+		if cur.Filter != fingerprint {
+			return CountryPage{}, fmt.Errorf("%w: it was issued for other filters or sort", ErrInvalidCursor)
+		}
+		key := db.CountryKey{ID: cur.ID}
+		if cur.Value != nil {
+			key.Value = sql.NullString{String: *cur.Value, Valid: true}
+		}
+		filter.After = &key
+		filter.Offset = 0
+		if filter.Limit == 0 {
+			filter.Limit = cur.Limit
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageLimit
+	}
+
+	total, err := c.CountCountries(filter)
+	if err != nil {
+		return CountryPage{}, err
+	}
+	// one extra row tells whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	countries, err := c.ListCountries(filter)
+	if err != nil {
+		return CountryPage{}, err
+	}
+	page := CountryPage{Countries: countries, Total: total}
+	if len(countries) > limit {
+		page.Countries = countries[:limit]
+		key := filter.KeyOf(page.Countries[limit-1])
+		next := pageCursor{Filter: fingerprint, ID: key.ID, Limit: limit}
+		if key.Value.Valid {
+			next.Value = &key.Value.String
+		}
+		if page.Next, err = c.encodeCursor(next); err != nil {
+			return CountryPage{}, err
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	value := "1500.00"
+	tests := []struct {
+		name string
+		cur  pageCursor
+	}{
+		{"with a sort value", pageCursor{Filter: "abc", Value: &value, ID: 42, Limit: 10}},
+		{"past the null values", pageCursor{Filter: "abc", ID: 42, Limit: 10}},
+	}
+	c := &CountryService{}
+	c.SetCursorSecret([]byte("secret"))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := c.encodeCursor(tt.cur)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.ContainsAny(s, "+/=") {
+				t.Errorf("cursor %q is not URL safe", s)
+			}
+			got, err := c.decodeCursor(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Filter != tt.cur.Filter || got.ID != tt.cur.ID || got.Limit != tt.cur.Limit ||
+				(got.Value == nil) != (tt.cur.Value == nil) || (got.Value != nil && *got.Value != *tt.cur.Value) {
+				t.Errorf("decodeCursor = %+v, want %+v", got, tt.cur)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	c := &CountryService{}
+	c.SetCursorSecret([]byte("secret"))
+	valid, err := c.encodeCursor(pageCursor{Filter: "abc", ID: 42, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"f":"abc","id":1,"l":10}`))
+
+	other := &CountryService{}
+	other.SetCursorSecret([]byte("another secret"))
+
+	tests := []struct {
+		name    string
+		service *CountryService
+		cursor  string
+	}{
+		{"no signature", c, payload},
+		{"forged payload", c, forged + "." + sig},
+		{"truncated signature", c, payload + "." + sig[:len(sig)-4]},
+		{"not base64", c, "***." + sig},
+		{"signature not base64", c, payload + ".***"},
+		{"signed json that is not a cursor", c, c.signCursor([]byte(`[1, 2]`))},
+		{"signed garbage", c, c.signCursor([]byte("not json"))},
+		{"signed by another secret", other, valid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.service.decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestCursorRandomSecret(t *testing.T) {
+	a, b := &CountryService{}, &CountryService{}
+	s, err := a.encodeCursor(pageCursor{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.decodeCursor(s); err != nil {
+		t.Errorf("own cursor rejected: %v", err)
+	}
+	if _, err := b.decodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor accepted by a service with another random secret: %v", err)
+	}
+}
+
+func TestFilterFingerprint(t *testing.T) {
+	gdp := 1e9
+	base := db.CountryFilter{Region: "Africa", Currency: "xof", Sort: db.SortGDPDesc, MinReportedGdp: &gdp}
+	same := []db.CountryFilter{
+		{Region: "africa", Currency: "XOF", Sort: db.SortGDPDesc, MinReportedGdp: &gdp},
+		// paging parameters are not part of the listing
+		{Region: "Africa", Currency: "xof", Sort: db.SortGDPDesc, MinReportedGdp: &gdp, Limit: 5, Offset: 10},
+	}
+	different := []db.CountryFilter{
+		{Region: "Africa", Currency: "xof", Sort: db.SortGDPAsc, MinReportedGdp: &gdp},
+		{Region: "Africa", Currency: "xof", Sort: db.SortGDPDesc},
+		{Region: "Africa", Currency: "xof", Sort: db.SortGDPDesc, MaxReportedGdp: &gdp},
+		{Region: "Europe", Currency: "xof", Sort: db.SortGDPDesc, MinReportedGdp: &gdp},
+	}
+	want := filterFingerprint(base)
+	for _, f := range same {
+		if got := filterFingerprint(f); got != want {
+			t.Errorf("fingerprint of %+v differs from %+v", f, base)
+		}
+	}
+	for _, f := range different {
+		if got := filterFingerprint(f); got == want {
+			t.Errorf("fingerprint of %+v matches %+v", f, base)
+		}
+	}
+}
+
+func TestListCountriesPageRejectsForeignCursor(t *testing.T) {
+	c := &CountryService{}
+	cursor, err := c.encodeCursor(pageCursor{Filter: filterFingerprint(db.CountryFilter{Sort: db.SortGDPDesc}), ID: 1, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cursor is checked before the database is touched
+	_, err = c.ListCountriesPage(db.CountryFilter{Sort: db.SortGDPAsc}, cursor)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListCountriesPage = %v, want %v", err, ErrInvalidCursor)
+	}
+	_, err = c.ListCountriesPage(db.CountryFilter{}, "garbage")
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("ListCountriesPage = %v, want %v", err, ErrInvalidCursor)
+	}
+}
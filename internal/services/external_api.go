@@ -1,16 +1,40 @@
 package internal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/franzego/stage02/models"
 )
 
+const (
+	DefaultCountriesURL   = "https://restcountries.com/v2/all?fields=name,capital,region,population,flag,currencies,alpha2Code,alpha3Code,numericCode"
+	DefaultCountriesV3URL = "https://restcountries.com/v3.1/all?fields=name,capital,region,population,flags,currencies,independent,cca2,cca3,ccn3"
+	DefaultRatesURL       = "https://open.er-api.com/v6/latest/USD"
+)
+
+// Default circuit breaker settings for each upstream
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// ExternalApi is the HTTP implementation of CountrySource and RateSource.
+// Each upstream has its own circuit breaker so one outage does not block
+// the other.
 type ExternalApi struct {
-	httpclient *http.Client
+	httpclient       *http.Client
+	countriesURL     string
+	countriesFormat  string
+	ratesURL         string
+	retry            RetryPolicy
+	countriesBreaker *CircuitBreaker
+	ratesBreaker     *CircuitBreaker
 }
 
 func NewExternalService() *ExternalApi {
@@ -18,43 +42,189 @@ func NewExternalService() *ExternalApi {
 		httpclient: &http.Client{
 			Timeout: 20 * time.Second,
 		},
+		countriesURL:     DefaultCountriesURL,
+		countriesFormat:  FormatV2,
+		ratesURL:         DefaultRatesURL,
+		retry:            DefaultRetryPolicy,
+		countriesBreaker: NewCircuitBreaker("restcountries", DefaultBreakerThreshold, DefaultBreakerCooldown),
+		ratesBreaker:     NewCircuitBreaker(ProviderERAPI, DefaultBreakerThreshold, DefaultBreakerCooldown),
+	}
+}
+
+// WithRetryPolicy overrides how failed upstream calls are retried
+func (e *ExternalApi) WithRetryPolicy(policy RetryPolicy) *ExternalApi {
+	e.retry = policy
+	return e
+}
+
+// WithBreakers overrides the circuit breaker threshold and cooldown
+func (e *ExternalApi) WithBreakers(threshold int, cooldown time.Duration) *ExternalApi {
+	e.countriesBreaker = NewCircuitBreaker("restcountries", threshold, cooldown)
+	e.ratesBreaker = NewCircuitBreaker(ProviderERAPI, threshold, cooldown)
+	return e
+}
+
+// Breakers reports the state of each upstream's circuit breaker
+func (e *ExternalApi) Breakers() []BreakerSnapshot {
+	return []BreakerSnapshot{
+		e.countriesBreaker.Snapshot(),
+		e.ratesBreaker.Snapshot(),
+	}
+}
+
+// WithCountriesFormat switches the restcountries schema and, unless WithURLs
+// sets one, the matching default endpoint
+func (e *ExternalApi) WithCountriesFormat(format string) *ExternalApi {
+	e.countriesFormat = format
+	if format == FormatV31 && e.countriesURL == DefaultCountriesURL {
+		e.countriesURL = DefaultCountriesV3URL
+	}
+	return e
+}
+
+// WithURLs points the client at different upstream endpoints
+func (e *ExternalApi) WithURLs(countriesURL, ratesURL string) *ExternalApi {
+	if countriesURL != "" {
+		e.countriesURL = countriesURL
 	}
+	if ratesURL != "" {
+		e.ratesURL = ratesURL
+	}
+	return e
+}
+
+func (e *ExternalApi) FetchAllCountries(ctx context.Context) ([]models.CountryData, error) {
+	return e.fetchCountries(ctx, e.countriesURL)
 }
 
-func (e *ExternalApi) FetchAllCountries() ([]models.CountryData, error) {
-	url := "https://restcountries.com/v2/all?fields=name,capital,region,population,flag,currencies"
-	resp, err := e.httpclient.Get(url)
+// FetchCountriesByName uses restcountries' /name endpoint with fullText, so
+// only exact (case-insensitive) name matches come back
+func (e *ExternalApi) FetchCountriesByName(ctx context.Context, name string) ([]models.CountryData, error) {
+	u, err := e.scopedURL("name", name)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("fullText", "true")
+	u.RawQuery = q.Encode()
+	return e.fetchCountries(ctx, u.String())
+}
+
+// FetchCountriesByRegion uses restcountries' /region endpoint
+func (e *ExternalApi) FetchCountriesByRegion(ctx context.Context, region string) ([]models.CountryData, error) {
+	u, err := e.scopedURL("region", region)
+	if err != nil {
+		return nil, err
+	}
+	return e.fetchCountries(ctx, u.String())
+}
+
+// scopedURL swaps the trailing /all of the configured countries URL for
+// /<kind>/<value>, keeping the version prefix and field list
+func (e *ExternalApi) scopedURL(kind, value string) (*url.URL, error) {
+	u, err := url.Parse(e.countriesURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid countries URL: %w", err)
+	}
+	base, ok := strings.CutSuffix(u.Path, "/all")
+	if !ok {
+		return nil, fmt.Errorf("countries URL %s does not end in /all, cannot derive a /%s endpoint", e.countriesURL, kind)
+	}
+	// escape the value on its own so a "/" in it stays part of the segment
+	u.Path = base + "/" + kind + "/" + value
+	u.RawPath = (&url.URL{Path: base}).EscapedPath() + "/" + kind + "/" + url.PathEscape(value)
+	return u, nil
+}
+
+// fetchCountries treats a 404 as no matches: the per-name and per-region
+// endpoints answer that way when nothing matches. When the endpoint picks its
+// fields, the detailed attributes are fetched by a second request and merged.
+func (e *ExternalApi) fetchCountries(ctx context.Context, endpoint string) ([]models.CountryData, error) {
+	countries, err := e.getCountries(ctx, endpoint)
+	if err != nil || len(countries) == 0 {
+		return countries, err
+	}
+	detailsEndpoint, ok := e.detailsURL(endpoint)
+	if !ok {
+		return countries, nil
+	}
+	details, err := e.getCountries(ctx, detailsEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("country details: %w", err)
+	}
+	mergeDetails(countries, details)
+	return countries, nil
+}
+
+// detailsURL swaps the field list of a countries endpoint for the details
+// fields. An endpoint without a field list already returns everything.
+func (e *ExternalApi) detailsURL(endpoint string) (string, bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false
+	}
+	q := u.Query()
+	if q.Get("fields") == "" {
+		return "", false
+	}
+	if e.countriesFormat == FormatV31 {
+		q.Set("fields", DetailFieldsV31)
+	} else {
+		q.Set("fields", DetailFieldsV2)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), true
+}
+
+func (e *ExternalApi) getCountries(ctx context.Context, endpoint string) ([]models.CountryData, error) {
+	resp, err := getWithRetry(ctx, e.httpclient, e.countriesBreaker, e.retry, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch countries: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound && endpoint != e.countriesURL {
+		return []models.CountryData{}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("restcountries API returned status %d", resp.StatusCode)
 	}
-	var countries []models.CountryData
-	if err := json.NewDecoder(resp.Body).Decode(&countries); err != nil {
+	countries, err := decodeCountries(resp.Body, e.countriesFormat)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse countries JSON: %w", err)
 	}
 	return countries, nil
 }
-func (e *ExternalApi) FetchExchangeRate() (map[string]float64, error) {
-	url := "https://open.er-api.com/v6/latest/USD"
-	resp, err := e.httpclient.Get(url)
+func (e *ExternalApi) FetchExchangeRate(ctx context.Context) (models.RateSet, error) {
+	resp, err := getWithRetry(ctx, e.httpclient, e.ratesBreaker, e.retry, e.ratesURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch exchange rate: %w", err)
+		return models.RateSet{}, fmt.Errorf("failed to fetch exchange rate: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("exchangereate API returned status %d", resp.StatusCode)
+		return models.RateSet{}, fmt.Errorf("exchangereate API returned status %d", resp.StatusCode)
 	}
 	var exRate models.ExchangeRateResponse
 	if err = json.NewDecoder(resp.Body).Decode(&exRate); err != nil {
-		return nil, fmt.Errorf("failed to parse exchange rate JSON: %w", err)
+		return models.RateSet{}, fmt.Errorf("failed to parse exchange rate JSON: %w", err)
 	}
 	if exRate.Result != "success" {
-		return nil, fmt.Errorf("exchange rate API returned unsuccessful result")
+		return models.RateSet{}, fmt.Errorf("exchange rate API returned unsuccessful result")
+	}
+	return models.RateSet{
+		Base:      baseOrUSD(exRate.BaseCode),
+		Rates:     exRate.Rates,
+		FetchedAt: time.Now().UTC().Truncate(time.Second),
+		Provider:  ProviderERAPI,
+	}, nil
+}
+
+// baseOrUSD defaults a missing base currency to USD, which is what every
+// configured endpoint quotes against
+func baseOrUSD(base string) string {
+	if base == "" {
+		return "USD"
 	}
-	return exRate.Rates, nil
+	return base
 }
@@ -0,0 +1,46 @@
+package internal
+
+import "testing"
+
+func TestScopedURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		countriesURL string
+		kind         string
+		value        string
+		want         string
+	}{
+		{
+			"keeps the version and fields",
+			DefaultCountriesURL, "region", "Africa",
+			"https://restcountries.com/v2/region/Africa?fields=name,capital,region,population,flag,currencies,alpha2Code,alpha3Code,numericCode",
+		},
+		{
+			"v3.1",
+			"https://restcountries.com/v3.1/all", "name", "Ghana",
+			"https://restcountries.com/v3.1/name/Ghana",
+		},
+		{
+			"escapes the value",
+			"https://restcountries.com/v2/all", "name", "Bosnia and Herzegovina/x",
+			"https://restcountries.com/v2/name/Bosnia%20and%20Herzegovina%2Fx",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExternalService().WithURLs(tt.countriesURL, "")
+			u, err := e.scopedURL(tt.kind, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := u.String(); got != tt.want {
+				t.Errorf("scopedURL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	e := NewExternalService().WithURLs("https://restcountries.com/v2/countries", "")
+	if _, err := e.scopedURL("name", "Ghana"); err == nil {
+		t.Error("scopedURL accepted a countries URL without /all")
+	}
+}
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/franzego/stage02/models"
+)
+
+// FileSource reads countries and exchange rates from local JSON snapshots.
+// The countries file uses a restcountries format (v2 unless WithFormat says
+// otherwise) and the rates file uses the open.er-api.com format, so a saved
+// API response works as is.
+type FileSource struct {
+	countriesPath   string
+	countriesFormat string
+	ratesPath       string
+}
+
+func NewFileSource(countriesPath, ratesPath string) *FileSource {
+	return &FileSource{
+		countriesPath:   countriesPath,
+		countriesFormat: FormatV2,
+		ratesPath:       ratesPath,
+	}
+}
+
+// WithFormat sets the restcountries schema of the countries file
+func (f *FileSource) WithFormat(format string) *FileSource {
+	f.countriesFormat = format
+	return f
+}
+
+func (f *FileSource) FetchAllCountries(ctx context.Context) ([]models.CountryData, error) {
+	file, err := os.Open(f.countriesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open countries file: %w", err)
+	}
+	defer file.Close()
+
+	countries, err := decodeCountries(file, f.countriesFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse countries file %s: %w", f.countriesPath, err)
+	}
+	return countries, nil
+}
+
+// FetchCountriesByName filters the snapshot locally by exact name
+func (f *FileSource) FetchCountriesByName(ctx context.Context, name string) ([]models.CountryData, error) {
+	return f.filterCountries(ctx, func(c models.CountryData) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+}
+
+// FetchCountriesByRegion filters the snapshot locally by region
+func (f *FileSource) FetchCountriesByRegion(ctx context.Context, region string) ([]models.CountryData, error) {
+	return f.filterCountries(ctx, func(c models.CountryData) bool {
+		return strings.EqualFold(c.Region, region)
+	})
+}
+
+func (f *FileSource) filterCountries(ctx context.Context, keep func(models.CountryData) bool) ([]models.CountryData, error) {
+	countries, err := f.FetchAllCountries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := []models.CountryData{}
+	for _, c := range countries {
+		if keep(c) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+// FetchExchangeRate dates the rate set by the snapshot's own
+// time_last_update_unix, falling back to the file's modification time, so
+// replaying the same file does not add new history points
+func (f *FileSource) FetchExchangeRate(ctx context.Context) (models.RateSet, error) {
+	file, err := os.Open(f.ratesPath)
+	if err != nil {
+		return models.RateSet{}, fmt.Errorf("failed to open exchange rate file: %w", err)
+	}
+	defer file.Close()
+
+	var exRate models.ExchangeRateResponse
+	if err := json.NewDecoder(file).Decode(&exRate); err != nil {
+		return models.RateSet{}, fmt.Errorf("failed to parse exchange rate file %s: %w", f.ratesPath, err)
+	}
+	if exRate.Result != "" && exRate.Result != "success" {
+		return models.RateSet{}, fmt.Errorf("exchange rate file has unsuccessful result %q", exRate.Result)
+	}
+	if len(exRate.Rates) == 0 {
+		return models.RateSet{}, fmt.Errorf("exchange rate file %s has no rates", f.ratesPath)
+	}
+
+	fetchedAt := time.Unix(exRate.TimeLastUpdateUnix, 0)
+	if exRate.TimeLastUpdateUnix == 0 {
+		info, err := file.Stat()
+		if err != nil {
+			return models.RateSet{}, fmt.Errorf("failed to stat exchange rate file: %w", err)
+		}
+		fetchedAt = info.ModTime()
+	}
+	return models.RateSet{
+		Base:      baseOrUSD(exRate.BaseCode),
+		Rates:     exRate.Rates,
+		FetchedAt: fetchedAt.UTC().Truncate(time.Second),
+		Provider:  ProviderFile,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSourceReadsSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	countries := filepath.Join(dir, "countries.json")
+	rates := filepath.Join(dir, "rates.json")
+	if err := os.WriteFile(countries, []byte(`[
+		{"name": "Ghana", "region": "Africa", "population": 31072940, "alpha2Code": "GH",
+		 "currencies": [{"code": "GHS", "name": "Ghanaian cedi", "symbol": "₵"}]},
+		{"name": "Japan", "region": "Asia", "population": 125836021, "alpha2Code": "JP"}
+	]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rates, []byte(`{"result": "success", "base_code": "USD",
+		"time_last_update_unix": 1725235201, "rates": {"GHS": 15.6, "JPY": 146.2}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFileSource(countries, rates)
+	ctx := context.Background()
+
+	all, err := f.FetchAllCountries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Name != "Ghana" || len(all[0].Currencies) != 1 || all[0].Currencies[0].Code != "GHS" {
+		t.Errorf("FetchAllCountries = %+v", all)
+	}
+	if got, err := f.FetchCountriesByName(ctx, "japan"); err != nil || len(got) != 1 || got[0].Name != "Japan" {
+		t.Errorf("FetchCountriesByName = %+v, %v", got, err)
+	}
+	if got, err := f.FetchCountriesByRegion(ctx, "Europe"); err != nil || len(got) != 0 {
+		t.Errorf("FetchCountriesByRegion = %+v, %v", got, err)
+	}
+
+	set, err := f.FetchExchangeRate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 9, 2, 0, 0, 1, 0, time.UTC); !set.FetchedAt.Equal(want) {
+		t.Errorf("FetchedAt = %s, want %s", set.FetchedAt, want)
+	}
+	if set.Base != "USD" || set.Rates["GHS"] != 15.6 || set.Provider != ProviderFile {
+		t.Errorf("FetchExchangeRate = %+v", set)
+	}
+}
+
+func TestFileSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`[{"name": "Ghana",`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.json")
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		path        string
+		wantMissing bool
+	}{
+		{"missing file", missing, true},
+		{"malformed json", malformed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFileSource(tt.path, tt.path)
+			if _, err := f.FetchAllCountries(ctx); err == nil || errors.Is(err, os.ErrNotExist) != tt.wantMissing {
+				t.Errorf("FetchAllCountries = %v", err)
+			}
+			if _, err := f.FetchExchangeRate(ctx); err == nil || errors.Is(err, os.ErrNotExist) != tt.wantMissing {
+				t.Errorf("FetchExchangeRate = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// DefaultFlagConcurrency is how many flags are downloaded at once
+const DefaultFlagConcurrency = 4
+
+// maxFlagSize caps a downloaded flag; real ones are a few kilobytes
+const maxFlagSize = 5 << 20
+
+// FlagFile is a mirrored flag on disk
+type FlagFile struct {
+	Path        string
+	ContentType string
+	// ContentHash is the SHA-256 of the file, which also names it
+	ContentHash string
+	FetchedAt   time.Time
+}
+
+// FlagMirrorResult summarises one mirroring pass
+type FlagMirrorResult struct {
+	Downloaded  int
+	NotModified int
+	Failed      int
+}
+
+// flagStore keeps the mirrored flags; *db.Queries implements it
+type flagStore interface {
+	GetFlag(ctx context.Context, urlHash string) (db.Flag, error)
+	UpsertFlag(ctx context.Context, arg db.UpsertFlagParams) error
+	TouchFlag(ctx context.Context, arg db.TouchFlagParams) error
+}
+
+// FlagMirror downloads flag images into a local directory so they can be
+// served without hotlinking the upstream CDN. Files are named by content
+// hash, so identical flags share a file, and known flags are revalidated
+// with the ETag and Last-Modified the CDN sent last time.
+type FlagMirror struct {
+	q           flagStore
+	httpclient  *http.Client
+	dir         string
+	retry       RetryPolicy
+	breaker     *CircuitBreaker
+	concurrency int
+}
+
+func NewFlagMirror(queries *db.Queries, dir string) *FlagMirror {
+	return &FlagMirror{
+		q: queries,
+		httpclient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+		dir:         dir,
+		retry:       DefaultRetryPolicy,
+		breaker:     NewCircuitBreaker("flags", DefaultBreakerThreshold, DefaultBreakerCooldown),
+		concurrency: DefaultFlagConcurrency,
+	}
+}
+
+// WithRetryPolicy overrides how failed downloads are retried
+func (m *FlagMirror) WithRetryPolicy(policy RetryPolicy) *FlagMirror {
+	m.retry = policy
+	return m
+}
+
+// WithBreaker overrides the circuit breaker threshold and cooldown
+func (m *FlagMirror) WithBreaker(threshold int, cooldown time.Duration) *FlagMirror {
+	m.breaker = NewCircuitBreaker("flags", threshold, cooldown)
+	return m
+}
+
+// WithConcurrency sets how many flags are downloaded at once
+func (m *FlagMirror) WithConcurrency(n int) *FlagMirror {
+	if n > 0 {
+		m.concurrency = n
+	}
+	return m
+}
+
+func (m *FlagMirror) Breakers() []BreakerSnapshot {
+	return []BreakerSnapshot{m.breaker.Snapshot()}
+}
+
+// SetFlagMirror makes refreshes mirror flags; nil turns mirroring off
+func (c *CountryService) SetFlagMirror(m *FlagMirror) {
+	c.flags = m
+}
+
+// flagKey is the primary key of a flag URL in the flags table
+func flagKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// flagExt picks the file extension for a flag's content type
+func flagExt(contentType string) string {
+	switch contentType {
+	case "image/svg+xml":
+		return ".svg"
+	case "image/png":
+		return ".png"
+	}
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+func (m *FlagMirror) filePath(contentHash, contentType string) string {
+	return filepath.Join(m.dir, contentHash+flagExt(contentType))
+}
+
+// Mirror downloads or revalidates every URL. Failures are logged and
+// counted but never stop the pass.
+func (m *FlagMirror) Mirror(ctx context.Context, urls []string) FlagMirrorResult {
+	var result FlagMirrorResult
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		log.Printf("flag mirror: could not create %s: %v", m.dir, err)
+		result.Failed = len(urls)
+		return result
+	}
+
+	seen := make(map[string]bool, len(urls))
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < m.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				downloaded, err := m.mirrorOne(ctx, url)
+				mu.Lock()
+				switch {
+				case err != nil:
+					log.Printf("flag mirror: %s: %v", url, err)
+					result.Failed++
+				case downloaded:
+					result.Downloaded++
+				default:
+					result.NotModified++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, url := range urls {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		jobs <- url
+	}
+	close(jobs)
+	wg.Wait()
+	return result
+}
+
+// mirrorOne fetches one flag, conditionally when a copy is already on
+// disk. It reports whether a new body was downloaded.
+func (m *FlagMirror) mirrorOne(ctx context.Context, url string) (bool, error) {
+	key := flagKey(url)
+	existing, err := m.q.GetFlag(ctx, key)
+	known := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("could not load flag: %w", err)
+	}
+
+	header := http.Header{}
+	if known {
+		// only revalidate when the file survived, otherwise refetch it
+		if _, err := os.Stat(m.filePath(existing.ContentHash, existing.ContentType)); err == nil {
+			if existing.Etag.Valid {
+				header.Set("If-None-Match", existing.Etag.String)
+			}
+			if existing.LastModified.Valid {
+				header.Set("If-Modified-Since", existing.LastModified.String)
+			}
+		}
+	}
+
+	resp, err := getWithRetryHeader(ctx, m.httpclient, m.breaker, m.retry, url, header)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if resp.StatusCode == http.StatusNotModified && len(header) > 0 {
+		if err := m.q.TouchFlag(ctx, db.TouchFlagParams{CheckedAt: now, UrlHash: key}); err != nil {
+			return false, fmt.Errorf("could not update flag: %w", err)
+		}
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("flag CDN returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFlagSize+1))
+	if err != nil {
+		return false, fmt.Errorf("could not read flag: %w", err)
+	}
+	if len(body) > maxFlagSize {
+		return false, fmt.Errorf("flag is larger than %d bytes", maxFlagSize)
+	}
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || contentType == "" {
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	sum := sha256.Sum256(body)
+	contentHash := hex.EncodeToString(sum[:])
+
+	path := m.filePath(contentHash, contentType)
+	if _, err := os.Stat(path); err != nil {
+		if err := writeFileAtomic(path, body); err != nil {
+			return false, err
+		}
+	}
+	if err := m.q.UpsertFlag(ctx, db.UpsertFlagParams{
+		UrlHash:      key,
+		Url:          url,
+		ContentHash:  contentHash,
+		ContentType:  contentType,
+		Size:         int64(len(body)),
+		Etag:         sql.NullString{String: resp.Header.Get("ETag"), Valid: resp.Header.Get("ETag") != ""},
+		LastModified: sql.NullString{String: resp.Header.Get("Last-Modified"), Valid: resp.Header.Get("Last-Modified") != ""},
+		FetchedAt:    now,
+		CheckedAt:    now,
+	}); err != nil {
+		return false, fmt.Errorf("could not save flag: %w", err)
+	}
+	return true, nil
+}
+
+// writeFileAtomic writes through a temporary file so a reader never sees a
+// partial flag
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".flag-*")
+	if err != nil {
+		return fmt.Errorf("could not create flag file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write flag file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write flag file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("could not write flag file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not store flag file: %w", err)
+	}
+	return nil
+}
+
+// Lookup finds the mirrored copy of a flag URL; false means it is not
+// mirrored or the file has gone missing
+func (m *FlagMirror) Lookup(ctx context.Context, url string) (FlagFile, bool, error) {
+	flag, err := m.q.GetFlag(ctx, flagKey(url))
+	if err == sql.ErrNoRows {
+		return FlagFile{}, false, nil
+	}
+	if err != nil {
+		return FlagFile{}, false, fmt.Errorf("could not load flag: %w", err)
+	}
+	path := m.filePath(flag.ContentHash, flag.ContentType)
+	if _, err := os.Stat(path); err != nil {
+		return FlagFile{}, false, nil
+	}
+	return FlagFile{
+		Path:        path,
+		ContentType: flag.ContentType,
+		ContentHash: flag.ContentHash,
+		FetchedAt:   flag.FetchedAt,
+	}, true, nil
+}
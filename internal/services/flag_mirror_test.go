@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// memFlagStore keeps flags in memory in place of the flags table
+type memFlagStore struct {
+	mu      sync.Mutex
+	flags   map[string]db.Flag
+	touched int
+}
+
+func (s *memFlagStore) GetFlag(ctx context.Context, urlHash string) (db.Flag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flag, ok := s.flags[urlHash]
+	if !ok {
+		return db.Flag{}, sql.ErrNoRows
+	}
+	return flag, nil
+}
+
+func (s *memFlagStore) UpsertFlag(ctx context.Context, arg db.UpsertFlagParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[arg.UrlHash] = db.Flag(arg)
+	return nil
+}
+
+func (s *memFlagStore) TouchFlag(ctx context.Context, arg db.TouchFlagParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flag := s.flags[arg.UrlHash]
+	flag.CheckedAt = arg.CheckedAt
+	s.flags[arg.UrlHash] = flag
+	s.touched++
+	return nil
+}
+
+const testFlagSVG = `<svg xmlns="http://www.w3.org/2000/svg"><rect width="3" height="2"/></svg>`
+
+// flagCDN serves testFlagSVG, answering conditional requests with 304 once
+// both validators match
+func flagCDN(t *testing.T, requests *[]http.Header) *httptest.Server {
+	const etag, lastModified = `"v1"`, "Mon, 02 Sep 2024 10:00:00 GMT"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Header.Clone())
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(testFlagSVG))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testFlagMirror(t *testing.T, store *memFlagStore) *FlagMirror {
+	m := NewFlagMirror(nil, t.TempDir()).WithRetryPolicy(RetryPolicy{}).WithConcurrency(1)
+	m.q = store
+	return m
+}
+
+func TestFlagMirrorNamesFilesByContentHash(t *testing.T) {
+	var requests []http.Header
+	srv := flagCDN(t, &requests)
+	store := &memFlagStore{flags: map[string]db.Flag{}}
+	m := testFlagMirror(t, store)
+	ctx := context.Background()
+
+	urls := []string{srv.URL + "/gh.svg", srv.URL + "/copy-of-gh.svg", srv.URL + "/gh.svg", ""}
+	result := m.Mirror(ctx, urls)
+	if result != (FlagMirrorResult{Downloaded: 2}) {
+		t.Fatalf("Mirror = %+v, want 2 downloads", result)
+	}
+
+	sum := sha256.Sum256([]byte(testFlagSVG))
+	hash := hex.EncodeToString(sum[:])
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// identical bodies share one file
+	if len(entries) != 1 || entries[0].Name() != hash+".svg" {
+		t.Fatalf("mirror dir holds %v, want only %s.svg", entries, hash)
+	}
+
+	flag, ok, err := m.Lookup(ctx, urls[0])
+	if err != nil || !ok {
+		t.Fatalf("Lookup = %v, %v", ok, err)
+	}
+	if flag.Path != filepath.Join(m.dir, hash+".svg") || flag.ContentHash != hash || flag.ContentType != "image/svg+xml" {
+		t.Errorf("Lookup = %+v", flag)
+	}
+	body, err := os.ReadFile(flag.Path)
+	if err != nil || string(body) != testFlagSVG {
+		t.Errorf("mirrored file = %q, %v", body, err)
+	}
+
+	if _, ok, err := m.Lookup(ctx, srv.URL+"/unknown.svg"); ok || err != nil {
+		t.Errorf("Lookup of an unmirrored flag = %v, %v", ok, err)
+	}
+}
+
+func TestFlagMirrorRevalidates(t *testing.T) {
+	var requests []http.Header
+	srv := flagCDN(t, &requests)
+	store := &memFlagStore{flags: map[string]db.Flag{}}
+	m := testFlagMirror(t, store)
+	ctx := context.Background()
+	url := srv.URL + "/gh.svg"
+
+	if result := m.Mirror(ctx, []string{url}); result.Downloaded != 1 {
+		t.Fatalf("first pass = %+v", result)
+	}
+	if requests[0].Get("If-None-Match") != "" || requests[0].Get("If-Modified-Since") != "" {
+		t.Errorf("first fetch was conditional: %v", requests[0])
+	}
+
+	if result := m.Mirror(ctx, []string{url}); result != (FlagMirrorResult{NotModified: 1}) {
+		t.Fatalf("second pass = %+v, want not modified", result)
+	}
+	if got := requests[1].Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("If-None-Match = %q", got)
+	}
+	if got := requests[1].Get("If-Modified-Since"); got != "Mon, 02 Sep 2024 10:00:00 GMT" {
+		t.Errorf("If-Modified-Since = %q", got)
+	}
+	if store.touched != 1 {
+		t.Errorf("flag touched %d times, want 1", store.touched)
+	}
+
+	// a flag whose file went missing is fetched again in full
+	flag, _, _ := m.Lookup(ctx, url)
+	if err := os.Remove(flag.Path); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := m.Lookup(ctx, url); ok {
+		t.Error("Lookup found a flag whose file is gone")
+	}
+	if result := m.Mirror(ctx, []string{url}); result.Downloaded != 1 {
+		t.Fatalf("third pass = %+v, want a download", result)
+	}
+	if requests[2].Get("If-None-Match") != "" {
+		t.Errorf("refetch of a missing file was conditional: %v", requests[2])
+	}
+	if _, err := os.Stat(flag.Path); err != nil {
+		t.Errorf("file was not restored: %v", err)
+	}
+}
+
+func TestFlagMirrorFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	store := &memFlagStore{flags: map[string]db.Flag{}}
+	m := testFlagMirror(t, store)
+
+	if result := m.Mirror(context.Background(), []string{srv.URL + "/missing.svg"}); result != (FlagMirrorResult{Failed: 1}) {
+		t.Errorf("Mirror = %+v, want a failure", result)
+	}
+	if len(store.flags) != 0 {
+		t.Errorf("failed download was stored: %v", store.flags)
+	}
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/franzego/stage02/models"
+)
+
+// DefaultFrankfurterURL is the public Frankfurter API, quoted against USD
+// like the primary provider
+const DefaultFrankfurterURL = "https://api.frankfurter.app/latest?from=USD"
+
+// frankfurterResponse is the body of a Frankfurter /latest call
+type frankfurterResponse struct {
+	Amount float64            `json:"amount"`
+	Base   string             `json:"base"`
+	Date   string             `json:"date"`
+	Rates  map[string]float64 `json:"rates"`
+}
+
+// FrankfurterSource is a RateSource for Frankfurter-compatible APIs. It
+// covers fewer currencies than open.er-api.com, so it is meant as a
+// fallback.
+type FrankfurterSource struct {
+	httpclient *http.Client
+	url        string
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
+}
+
+func NewFrankfurterSource(url string) *FrankfurterSource {
+	if url == "" {
+		url = DefaultFrankfurterURL
+	}
+	return &FrankfurterSource{
+		httpclient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+		url:     url,
+		retry:   DefaultRetryPolicy,
+		breaker: NewCircuitBreaker(ProviderFrankfurter, DefaultBreakerThreshold, DefaultBreakerCooldown),
+	}
+}
+
+// WithRetryPolicy overrides how failed calls are retried
+func (f *FrankfurterSource) WithRetryPolicy(policy RetryPolicy) *FrankfurterSource {
+	f.retry = policy
+	return f
+}
+
+// WithBreaker overrides the circuit breaker threshold and cooldown
+func (f *FrankfurterSource) WithBreaker(threshold int, cooldown time.Duration) *FrankfurterSource {
+	f.breaker = NewCircuitBreaker(ProviderFrankfurter, threshold, cooldown)
+	return f
+}
+
+func (f *FrankfurterSource) Breakers() []BreakerSnapshot {
+	return []BreakerSnapshot{f.breaker.Snapshot()}
+}
+
+// FetchExchangeRate adds the base currency at 1, which Frankfurter leaves
+// out of its rates but er-api includes
+func (f *FrankfurterSource) FetchExchangeRate(ctx context.Context) (models.RateSet, error) {
+	resp, err := getWithRetry(ctx, f.httpclient, f.breaker, f.retry, f.url)
+	if err != nil {
+		return models.RateSet{}, fmt.Errorf("failed to fetch exchange rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.RateSet{}, fmt.Errorf("frankfurter API returned status %d", resp.StatusCode)
+	}
+	var body frankfurterResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return models.RateSet{}, fmt.Errorf("failed to parse exchange rate JSON: %w", err)
+	}
+	if len(body.Rates) == 0 {
+		return models.RateSet{}, fmt.Errorf("frankfurter API returned no rates")
+	}
+	if body.Amount != 0 && body.Amount != 1 {
+		// rates are quoted for this many units of the base currency
+		for code, rate := range body.Rates {
+			body.Rates[code] = rate / body.Amount
+		}
+	}
+
+	base := baseOrUSD(body.Base)
+	body.Rates[base] = 1
+	return models.RateSet{
+		Base:      base,
+		Rates:     body.Rates,
+		FetchedAt: time.Now().UTC().Truncate(time.Second),
+		Provider:  ProviderFrankfurter,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// GDP estimation strategies stored in countries.gdp_strategy
+const (
+	GDPRandom = "random"
+	GDPFixed  = "fixed"
+	GDPFile   = "file"
+)
+
+// DefaultGDPMultiplier is the midpoint of the random model's range
+const DefaultGDPMultiplier = 1500.0
+
+// ErrUnknownGDPStrategy is returned for a strategy name that is not supported
+var ErrUnknownGDPStrategy = errors.New("unknown gdp strategy")
+
+// GDPEstimate is an estimated GDP together with how it was reached
+type GDPEstimate struct {
+	Value      float64
+	Multiplier float64
+	Strategy   string
+}
+
+// GDPEstimator turns a country's population and exchange rate into an
+// estimated GDP as population * multiplier / rate
+type GDPEstimator interface {
+	Estimate(name string, population int64, rate float64) GDPEstimate
+}
+
+// RandomEstimator draws a multiplier between 1000 and 2000. The draw is
+// seeded by the configured seed and the country name, so a country keeps
+// its multiplier across refreshes for as long as the seed stays the same.
+type RandomEstimator struct {
+	seed int64
+}
+
+func NewRandomEstimator(seed int64) *RandomEstimator {
+	return &RandomEstimator{seed: seed}
+}
+
+func (e *RandomEstimator) Estimate(name string, population int64, rate float64) GDPEstimate {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(name)))
+	r := rand.New(rand.NewSource(e.seed ^ int64(h.Sum64())))
+	multiplier := r.Float64()*1000 + 1000
+	return GDPEstimate{
+		Value:      estimateGDP(population, multiplier, rate),
+		Multiplier: multiplier,
+		Strategy:   GDPRandom,
+	}
+}
+
+// FixedEstimator applies the same multiplier to every country
+type FixedEstimator struct {
+	multiplier float64
+}
+
+func NewFixedEstimator(multiplier float64) *FixedEstimator {
+	return &FixedEstimator{multiplier: multiplier}
+}
+
+func (e *FixedEstimator) Estimate(name string, population int64, rate float64) GDPEstimate {
+	return GDPEstimate{
+		Value:      estimateGDP(population, e.multiplier, rate),
+		Multiplier: e.multiplier,
+		Strategy:   GDPFixed,
+	}
+}
+
+// FileEstimator uses per-country multipliers from a JSON object keyed by
+// country name, e.g. {"Nigeria": 1200.5}. Countries missing from the file
+// are estimated by the fallback, and recorded under its strategy.
+type FileEstimator struct {
+	multipliers map[string]float64
+	fallback    GDPEstimator
+}
+
+// LoadFileEstimator reads a multiplier file. Names are matched
+// case-insensitively.
+func LoadFileEstimator(path string, fallback GDPEstimator) (*FileEstimator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gdp multiplier file: %w", err)
+	}
+	var raw map[string]float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse gdp multiplier file %s: %w", path, err)
+	}
+	multipliers := make(map[string]float64, len(raw))
+	for name, m := range raw {
+		if m <= 0 {
+			return nil, fmt.Errorf("gdp multiplier for %s must be positive, got %v", name, m)
+		}
+		multipliers[strings.ToLower(name)] = m
+	}
+	return &FileEstimator{multipliers: multipliers, fallback: fallback}, nil
+}
+
+func (e *FileEstimator) Estimate(name string, population int64, rate float64) GDPEstimate {
+	multiplier, ok := e.multipliers[strings.ToLower(name)]
+	if !ok {
+		return e.fallback.Estimate(name, population, rate)
+	}
+	return GDPEstimate{
+		Value:      estimateGDP(population, multiplier, rate),
+		Multiplier: multiplier,
+		Strategy:   GDPFile,
+	}
+}
+
+// NewGDPEstimator builds the estimator for a strategy name. The file
+// strategy falls back to the fixed multiplier for countries it does not list.
+func NewGDPEstimator(strategy string, seed int64, multiplier float64, file string) (GDPEstimator, error) {
+	switch strategy {
+	case GDPRandom:
+		return NewRandomEstimator(seed), nil
+	case GDPFixed:
+		return NewFixedEstimator(multiplier), nil
+	case GDPFile:
+		if file == "" {
+			return nil, fmt.Errorf("gdp strategy %q needs a multiplier file", strategy)
+		}
+		return LoadFileEstimator(file, NewFixedEstimator(multiplier))
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownGDPStrategy, strategy)
+}
+
+func estimateGDP(population int64, multiplier, rate float64) float64 {
+	return float64(population) * multiplier / rate
+}
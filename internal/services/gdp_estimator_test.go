@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRandomEstimatorIsDeterministic(t *testing.T) {
+	a := NewRandomEstimator(42).Estimate("Ghana", 1000, 2)
+	b := NewRandomEstimator(42).Estimate("ghana", 1000, 2)
+	if a != b {
+		t.Errorf("same seed and name gave %+v and %+v", a, b)
+	}
+	if a.Multiplier < 1000 || a.Multiplier >= 2000 {
+		t.Errorf("multiplier %v is outside [1000, 2000)", a.Multiplier)
+	}
+	if want := 1000 * a.Multiplier / 2; math.Abs(a.Value-want) > 1e-6 || a.Strategy != GDPRandom {
+		t.Errorf("estimate = %+v, want value %v under %s", a, want, GDPRandom)
+	}
+
+	if other := NewRandomEstimator(43).Estimate("Ghana", 1000, 2); other.Multiplier == a.Multiplier {
+		t.Errorf("seeds 42 and 43 gave the same multiplier %v", a.Multiplier)
+	}
+	if other := NewRandomEstimator(42).Estimate("Togo", 1000, 2); other.Multiplier == a.Multiplier {
+		t.Errorf("Ghana and Togo got the same multiplier %v", a.Multiplier)
+	}
+}
+
+func TestFileEstimator(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	e, err := NewGDPEstimator(GDPFile, 0, 1500, write("ok.json", `{"Nigeria": 1200.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Estimate("NIGERIA", 10, 1); got != (GDPEstimate{Value: 12005, Multiplier: 1200.5, Strategy: GDPFile}) {
+		t.Errorf("listed country = %+v", got)
+	}
+	if got := e.Estimate("Ghana", 10, 2); got != (GDPEstimate{Value: 7500, Multiplier: 1500, Strategy: GDPFixed}) {
+		t.Errorf("unlisted country = %+v, want the fixed fallback", got)
+	}
+
+	for name, contents := range map[string]string{
+		"negative.json":  `{"Nigeria": -1}`,
+		"malformed.json": `{"Nigeria": `,
+	} {
+		if _, err := LoadFileEstimator(write(name, contents), nil); err == nil {
+			t.Errorf("%s loaded without error", name)
+		}
+	}
+	if _, err := NewGDPEstimator(GDPFile, 0, 1500, ""); err == nil {
+		t.Error("file strategy without a file succeeded")
+	}
+	if _, err := NewGDPEstimator("magic", 0, 1500, ""); !errors.Is(err, ErrUnknownGDPStrategy) {
+		t.Errorf("unknown strategy: %v", err)
+	}
+}
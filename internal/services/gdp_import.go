@@ -0,0 +1,311 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// Errors returned by the reported GDP import
+var (
+	// ErrInvalidGDPCSV wraps every problem with the CSV contents
+	ErrInvalidGDPCSV = errors.New("invalid gdp csv")
+	// ErrNoGDPHeader is returned when a CSV has no recognisable header row
+	ErrNoGDPHeader = errors.New("no country/GDP header row found")
+	// ErrBadImportFile is returned for a file name outside the import dir
+	ErrBadImportFile = errors.New("import file must be a plain file name")
+)
+
+// GDPImportResult summarises one reported GDP import
+type GDPImportResult struct {
+	// Rows is the number of country rows read with a usable GDP value
+	Rows    int
+	Updated int
+	// Unmatched lists the codes or names that matched no stored country
+	Unmatched []string
+}
+
+// gdpRecord is the latest reported GDP found for one CSV country
+type gdpRecord struct {
+	code  string
+	name  string
+	year  int
+	value float64
+}
+
+// SetImportDir sets the directory the import endpoint may read files from
+func (c *CountryService) SetImportDir(dir string) {
+	c.importDir = dir
+}
+
+// ImportReportedGDPFromDir imports a CSV by name from the import directory.
+// Only plain file names are accepted so callers cannot read elsewhere.
+func (c *CountryService) ImportReportedGDPFromDir(ctx context.Context, name string) (GDPImportResult, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return GDPImportResult{}, fmt.Errorf("%w: %q", ErrBadImportFile, name)
+	}
+	return c.ImportReportedGDPFile(ctx, filepath.Join(c.importDir, name))
+}
+
+// ImportReportedGDPFile imports a World Bank style GDP CSV from disk
+func (c *CountryService) ImportReportedGDPFile(ctx context.Context, path string) (GDPImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return GDPImportResult{}, fmt.Errorf("failed to open gdp file: %w", err)
+	}
+	defer file.Close()
+	return c.ImportReportedGDP(ctx, file)
+}
+
+// ImportReportedGDP stores reported GDP figures from a CSV. Two layouts are
+// accepted: one row per country and year (country code or name, year, GDP),
+// or the World Bank wide export with one column per year, where the latest
+// non-empty year is used. Rows are matched to countries by ISO 3166-1 code
+// (alpha-3 as the World Bank uses, alpha-2 or numeric), falling back to the
+// name; aggregates such as "World" simply stay unmatched. All updates happen
+// in one transaction.
+func (c *CountryService) ImportReportedGDP(ctx context.Context, r io.Reader) (GDPImportResult, error) {
+	var result GDPImportResult
+	records, err := parseGDPCSV(r)
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidGDPCSV, err)
+	}
+	result.Rows = len(records)
+
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := c.q.WithTx(tx)
+
+	countries, err := qtx.GetAllCountries(ctx)
+	if err != nil {
+		return result, fmt.Errorf("could not load countries: %w", err)
+	}
+	byName := make(map[string]int64, len(countries))
+	byCode := make(map[string]int64, 3*len(countries))
+	for _, country := range countries {
+		byName[strings.ToLower(country.Name)] = country.ID
+		for _, code := range []sql.NullString{country.Alpha2Code, country.Alpha3Code, country.NumericCode} {
+			if code.Valid && code.String != "" {
+				byCode[gdpCodeKey(code.String)] = country.ID
+			}
+		}
+	}
+
+	for _, rec := range records {
+		id, ok := byCode[gdpCodeKey(rec.code)]
+		if !ok {
+			id, ok = byName[strings.ToLower(rec.name)]
+		}
+		if !ok {
+			result.Unmatched = append(result.Unmatched, rec.label())
+			continue
+		}
+		if err := qtx.UpdateReportedGDP(ctx, db.UpdateReportedGDPParams{
+			ReportedGdp:     sql.NullString{String: strconv.FormatFloat(rec.value, 'f', 2, 64), Valid: true},
+			ReportedGdpYear: sql.NullInt32{Int32: int32(rec.year), Valid: true},
+			ID:              id,
+		}); err != nil {
+			return result, fmt.Errorf("could not store reported gdp for %s: %w", rec.label(), err)
+		}
+		result.Updated++
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("could not commit gdp import: %w", err)
+	}
+	sort.Strings(result.Unmatched)
+	return result, nil
+}
+
+// gdpCodeKey normalises an ISO code for matching. Spreadsheets often drop
+// the leading zeros of numeric codes, so those are padded back to three
+// digits.
+func gdpCodeKey(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) > 0 && len(code) < 3 {
+		if padded := strings.Repeat("0", 3-len(code)) + code; numericPattern.MatchString(padded) {
+			return padded
+		}
+	}
+	return code
+}
+
+func (r gdpRecord) label() string {
+	if r.name != "" {
+		return r.name
+	}
+	return r.code
+}
+
+// key identifies a CSV country, preferring the code when there is one
+func (r gdpRecord) key() string {
+	if r.code != "" {
+		return strings.ToUpper(r.code)
+	}
+	return strings.ToLower(r.name)
+}
+
+// gdpColumns are the header positions of a GDP CSV; -1 means absent
+type gdpColumns struct {
+	code, name, year, value int
+	// years maps column index to year for the wide layout
+	years map[int]int
+}
+
+// parseGDPCSV skips any preamble (the World Bank export has four lines of
+// metadata) until a header row, then keeps the latest year per country
+func parseGDPCSV(r io.Reader) ([]gdpRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var cols *gdpColumns
+	latest := map[string]gdpRecord{}
+	var order []string
+	line := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if cols == nil {
+			cols = gdpHeader(row)
+			continue
+		}
+
+		rec, ok, err := cols.record(row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !ok {
+			continue
+		}
+		key := rec.key()
+		prev, seen := latest[key]
+		if !seen {
+			order = append(order, key)
+		}
+		if !seen || rec.year > prev.year {
+			latest[key] = rec
+		}
+	}
+	if cols == nil {
+		return nil, ErrNoGDPHeader
+	}
+
+	records := make([]gdpRecord, 0, len(order))
+	for _, key := range order {
+		records = append(records, latest[key])
+	}
+	return records, nil
+}
+
+// gdpHeader recognises a header row, returning nil for anything else
+func gdpHeader(row []string) *gdpColumns {
+	cols := &gdpColumns{code: -1, name: -1, year: -1, value: -1, years: map[int]int{}}
+	for i, v := range row {
+		h := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(v, "\ufeff")))
+		switch {
+		case h == "country code" || h == "code" || h == "iso3" || h == "iso2" || h == "iso_code":
+			cols.code = i
+		case h == "country name" || h == "country" || h == "name":
+			cols.name = i
+		case h == "year" || h == "date":
+			cols.year = i
+		case h == "gdp" || h == "value" || h == "gdp_usd" || strings.HasPrefix(h, "gdp ("):
+			cols.value = i
+		default:
+			if y, err := strconv.Atoi(h); err == nil && len(h) == 4 {
+				cols.years[i] = y
+			}
+		}
+	}
+	if cols.code < 0 && cols.name < 0 {
+		return nil
+	}
+	if cols.year >= 0 && cols.value >= 0 {
+		cols.years = nil
+		return cols
+	}
+	if len(cols.years) > 0 {
+		return cols
+	}
+	return nil
+}
+
+// record reads one data row. Rows without a value (blank or the World
+// Bank's "..") are skipped.
+func (cols *gdpColumns) record(row []string) (gdpRecord, bool, error) {
+	var rec gdpRecord
+	rec.code = cell(row, cols.code)
+	rec.name = cell(row, cols.name)
+	if rec.code == "" && rec.name == "" {
+		return rec, false, nil
+	}
+
+	if cols.years == nil {
+		v := cell(row, cols.value)
+		if v == "" || v == ".." {
+			return rec, false, nil
+		}
+		year, err := strconv.Atoi(cell(row, cols.year))
+		if err != nil {
+			return rec, false, fmt.Errorf("invalid year for %s: %w", rec.label(), err)
+		}
+		value, err := parseGDP(v)
+		if err != nil {
+			return rec, false, fmt.Errorf("invalid gdp for %s: %w", rec.label(), err)
+		}
+		rec.year, rec.value = year, value
+		return rec, true, nil
+	}
+
+	for i, year := range cols.years {
+		v := cell(row, i)
+		if v == "" || v == ".." || year <= rec.year {
+			continue
+		}
+		value, err := parseGDP(v)
+		if err != nil {
+			return rec, false, fmt.Errorf("invalid gdp for %s in %d: %w", rec.label(), year, err)
+		}
+		rec.year, rec.value = year, value
+	}
+	return rec, rec.year != 0, nil
+}
+
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// parseGDP reads a GDP figure, which must be a finite, non-negative number
+func parseGDP(v string) (float64, error) {
+	value, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+		return 0, fmt.Errorf("%q is not a finite, non-negative amount", v)
+	}
+	return value, nil
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGDPCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []gdpRecord
+	}{
+		{
+			name: "long layout keeps the latest year",
+			csv: `Country Code,Year,GDP
+GHA,2021,77594279819.81
+GHA,2022,72838834844.56
+TGO,2022,8126439578.18
+GHA,2020,70043199814.76
+`,
+			want: []gdpRecord{
+				{code: "GHA", year: 2022, value: 72838834844.56},
+				{code: "TGO", year: 2022, value: 8126439578.18},
+			},
+		},
+		{
+			name: "world bank wide export with preamble",
+			csv: "\ufeff\"Data Source\",\"World Development Indicators\",\n" +
+				"\n" +
+				"\"Last Updated Date\",\"2024-06-28\",\n" +
+				"\n" +
+				"\"Country Name\",\"Country Code\",\"Indicator Name\",\"Indicator Code\",\"2021\",\"2022\",\"2023\",\n" +
+				"\"Ghana\",\"GHA\",\"GDP (current US$)\",\"NY.GDP.MKTP.CD\",\"77594279819.8\",\"72838834844.6\",\"\",\n" +
+				"\"World\",\"WLD\",\"GDP (current US$)\",\"NY.GDP.MKTP.CD\",\"97531000000000\",\"100562000000000\",\"105435000000000\",\n" +
+				"\"Nowhere\",\"XXX\",\"GDP (current US$)\",\"NY.GDP.MKTP.CD\",\"\",\"\",\"\",\n",
+			want: []gdpRecord{
+				{code: "GHA", name: "Ghana", year: 2022, value: 72838834844.6},
+				{code: "WLD", name: "World", year: 2023, value: 105435000000000},
+			},
+		},
+		{
+			name: "names without codes and missing values",
+			csv: `country,date,value
+Ghana,2022,72838834844.56
+Togo,2022,..
+Benin,2022,
+`,
+			want: []gdpRecord{
+				{name: "Ghana", year: 2022, value: 72838834844.56},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGDPCSV(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGDPCSV = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGDPCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		wantErr error
+		detail  string
+	}{
+		{"no header", "GHA,2022,1\nTGO,2022,2\n", ErrNoGDPHeader, ""},
+		{"empty", "", ErrNoGDPHeader, ""},
+		{"bad year", "code,year,gdp\nGHA,last,1\n", nil, "line 2: invalid year for GHA"},
+		{"bad value", "code,year,gdp\nGHA,2022,lots\n", nil, "line 2: invalid gdp for GHA"},
+		{"bad wide value", "Country Code,2022\nGHA,n/a\n", nil, "invalid gdp for GHA in 2022"},
+		{"not a number", "code,year,gdp\nGHA,2022,NaN\n", nil, "line 2: invalid gdp for GHA"},
+		{"infinite", "code,year,gdp\nGHA,2022,Inf\n", nil, "line 2: invalid gdp for GHA"},
+		{"out of range", "code,year,gdp\nGHA,2022,1e400\n", nil, "line 2: invalid gdp for GHA"},
+		{"negative", "code,year,gdp\nGHA,2022,-5\n", nil, "line 2: invalid gdp for GHA"},
+		{"negative wide value", "Country Code,2022\nGHA,-1e9\n", nil, "invalid gdp for GHA in 2022"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGDPCSV(strings.NewReader(tt.csv))
+			if err == nil {
+				t.Fatal("parseGDPCSV succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.detail) {
+				t.Errorf("err = %q, want it to mention %q", err, tt.detail)
+			}
+		})
+	}
+}
+
+func TestGDPCodeKey(t *testing.T) {
+	tests := map[string]string{
+		"gha":  "GHA",
+		" gh ": "GH",
+		"288":  "288",
+		"4":    "004",
+		"36":   "036",
+		"":     "",
+	}
+	for code, want := range tests {
+		if got := gdpCodeKey(code); got != want {
+			t.Errorf("gdpCodeKey(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
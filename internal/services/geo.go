@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// Points a distance can be measured from
+const (
+	DistanceCentroid = "centroid"
+	// DistanceCapital uses the capital, falling back to the centroid for
+	// countries whose capital has no coordinates
+	DistanceCapital = "capital"
+)
+
+// EarthRadiusKM is the mean Earth radius used for great-circle distances
+const EarthRadiusKM = 6371.0
+
+var (
+	// ErrUnknownBasis is returned for a distance basis other than centroid
+	// or capital
+	ErrUnknownBasis = errors.New("unknown distance basis")
+	// ErrNoCoordinates is returned when a country has no stored coordinates
+	ErrNoCoordinates = errors.New("country has no coordinates")
+)
+
+// CountryDistance is a country and its distance from a point
+type CountryDistance struct {
+	Country    db.Country
+	DistanceKM float64
+}
+
+// DistanceResult is the distance between two countries, measured between
+// centroids and, when both are known, between capitals
+type DistanceResult struct {
+	From       db.Country
+	To         db.Country
+	CentroidKM *float64
+	CapitalKM  *float64
+}
+
+// ValidateBasis checks a distance basis name
+func ValidateBasis(basis string) error {
+	switch basis {
+	case DistanceCentroid, DistanceCapital:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownBasis, basis)
+}
+
+// HaversineKM is the great-circle distance between two points in degrees
+func HaversineKM(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// centroid returns a country's stored centroid
+func centroid(country db.Country) (float64, float64, bool) {
+	if !country.Latitude.Valid || !country.Longitude.Valid {
+		return 0, 0, false
+	}
+	return parseNullStringFloat(country.Latitude), parseNullStringFloat(country.Longitude), true
+}
+
+// capital returns a country's stored capital coordinates
+func capital(country db.Country) (float64, float64, bool) {
+	if !country.CapitalLatitude.Valid || !country.CapitalLongitude.Valid {
+		return 0, 0, false
+	}
+	return parseNullStringFloat(country.CapitalLatitude), parseNullStringFloat(country.CapitalLongitude), true
+}
+
+// position picks the point of a country to measure from for a basis
+func position(country db.Country, basis string) (float64, float64, bool) {
+	if basis == DistanceCapital {
+		if lat, lng, ok := capital(country); ok {
+			return lat, lng, true
+		}
+	}
+	return centroid(country)
+}
+
+// function to get the countries closest to a point, nearest first.
+// Countries without coordinates are left out.
+func (c *CountryService) NearestCountries(lat, lng float64, limit int, basis string) ([]CountryDistance, error) {
+	if err := ValidateBasis(basis); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	countries, err := c.q.GetAllCountries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load countries: %w", err)
+	}
+	var nearest []CountryDistance
+	for _, country := range countries {
+		clat, clng, ok := position(country, basis)
+		if !ok {
+			continue
+		}
+		nearest = append(nearest, CountryDistance{Country: country, DistanceKM: HaversineKM(lat, lng, clat, clng)})
+	}
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return nearest[i].DistanceKM < nearest[j].DistanceKM
+	})
+	if limit > 0 && len(nearest) > limit {
+		nearest = nearest[:limit]
+	}
+	return nearest, nil
+}
+
+// function to get the distance between two countries, by name or ISO code.
+// Returns sql.ErrNoRows if either is unknown and ErrNoCoordinates if no
+// distance can be measured.
+func (c *CountryService) GetDistance(from, to string) (DistanceResult, error) {
+	ctx := context.Background()
+	var result DistanceResult
+	var err error
+	if result.From, err = findCountry(ctx, c.q, from); err != nil {
+		return result, lookupError(err)
+	}
+	if result.To, err = findCountry(ctx, c.q, to); err != nil {
+		return result, lookupError(err)
+	}
+
+	if lat1, lng1, ok := centroid(result.From); ok {
+		if lat2, lng2, ok := centroid(result.To); ok {
+			km := HaversineKM(lat1, lng1, lat2, lng2)
+			result.CentroidKM = &km
+		}
+	}
+	if lat1, lng1, ok := capital(result.From); ok {
+		if lat2, lng2, ok := capital(result.To); ok {
+			km := HaversineKM(lat1, lng1, lat2, lng2)
+			result.CapitalKM = &km
+		}
+	}
+	if result.CentroidKM == nil && result.CapitalKM == nil {
+		return result, fmt.Errorf("%w: cannot measure %s to %s", ErrNoCoordinates, result.From.Name, result.To.Name)
+	}
+	return result, nil
+}
+
+// lookupError passes sql.ErrNoRows through untouched so callers can compare
+// against it, and wraps anything else
+func lookupError(err error) error {
+	if err == sql.ErrNoRows {
+		return err
+	}
+	return fmt.Errorf("could not get country: %w", err)
+}
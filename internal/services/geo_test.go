@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func TestHaversineKM(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{"same point", 51.5, -0.12, 51.5, -0.12, 0},
+		{"quarter meridian", 0, 0, 90, 0, math.Pi / 2 * EarthRadiusKM},
+		{"antipodes", 0, 0, 0, 180, math.Pi * EarthRadiusKM},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.19},
+		{"london to paris", 51.5074, -0.1278, 48.8566, 2.3522, 343.56},
+		{"accra to lomé", 5.55, -0.22, 6.13, 1.22, 172.28},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HaversineKM(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("HaversineKM = %.2f, want %.2f", got, tt.want)
+			}
+			if back := HaversineKM(tt.lat2, tt.lng2, tt.lat1, tt.lng1); math.Abs(back-got) > 1e-9 {
+				t.Errorf("distance is not symmetric: %.6f and %.6f", got, back)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	both := db.Country{
+		Latitude: nullString("8"), Longitude: nullString("-2"),
+		CapitalLatitude: nullString("5.55"), CapitalLongitude: nullString("-0.22"),
+	}
+	centroidOnly := db.Country{Latitude: nullString("8"), Longitude: nullString("-2")}
+	tests := []struct {
+		name     string
+		country  db.Country
+		basis    string
+		lat, lng float64
+		ok       bool
+	}{
+		{"centroid", both, DistanceCentroid, 8, -2, true},
+		{"capital", both, DistanceCapital, 5.55, -0.22, true},
+		{"capital falls back to centroid", centroidOnly, DistanceCapital, 8, -2, true},
+		{"no coordinates", db.Country{}, DistanceCapital, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng, ok := position(tt.country, tt.basis)
+			if lat != tt.lat || lng != tt.lng || ok != tt.ok {
+				t.Errorf("position = %v, %v, %v, want %v, %v, %v", lat, lng, ok, tt.lat, tt.lng, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateBasis(t *testing.T) {
+	for _, basis := range []string{DistanceCentroid, DistanceCapital} {
+		if err := ValidateBasis(basis); err != nil {
+			t.Errorf("ValidateBasis(%q) = %v", basis, err)
+		}
+	}
+	if err := ValidateBasis("border"); !errors.Is(err, ErrUnknownBasis) {
+		t.Errorf("ValidateBasis(border) = %v, want %v", err, ErrUnknownBasis)
+	}
+}
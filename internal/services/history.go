@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// writeHistory versions every country a refresh added or modified: the open
+// version is closed and a new one starts at the same instant. A version is
+// a snapshot of the stored row with its currencies and languages, keyed by
+// country id so a renamed country keeps its history. Countries deleted by a
+// reconcile have their version closed.
+func (c *CountryService) writeHistory(ctx context.Context, qtx *db.Queries, report ChangeReport, reconcile string) error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var closing []int64
+	versioned := make(map[string]bool, len(report.Changes))
+	for _, change := range report.Changes {
+		switch change.Type {
+		case ChangeRemoved:
+			if reconcile == ReconcileDelete {
+				closing = append(closing, change.ID)
+			}
+		case ChangeAdded, ChangeModified:
+			versioned[strings.ToLower(change.Name)] = true
+		}
+	}
+
+	var versions []db.CreateCountryHistoryParams
+	if len(versioned) > 0 {
+		stored, err := qtx.GetAllCountries(ctx)
+		if err != nil {
+			return fmt.Errorf("could not load countries for history: %w", err)
+		}
+		currencies, err := storedCurrencies(ctx, qtx)
+		if err != nil {
+			return err
+		}
+		languages, err := storedLanguages(ctx, qtx)
+		if err != nil {
+			return err
+		}
+		for _, country := range stored {
+			if !versioned[strings.ToLower(country.Name)] {
+				continue
+			}
+			closing = append(closing, country.ID)
+			versions = append(versions, historySnapshot(country, currencies[country.ID], languages[country.ID], now))
+		}
+	}
+
+	for start := 0; start < len(closing); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(closing))
+		if err := qtx.CloseCountryHistories(ctx, closing[start:end], now); err != nil {
+			return fmt.Errorf("could not close country history: %w", err)
+		}
+	}
+	for start := 0; start < len(versions); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(versions))
+		if err := qtx.CreateCountryHistories(ctx, versions[start:end]); err != nil {
+			return fmt.Errorf("could not save country history: %w", err)
+		}
+	}
+	return nil
+}
+
+// historySnapshot turns a stored row into a history version starting at
+// validFrom. currencies and languages are JSON lists of codes, in order.
+func historySnapshot(country db.Country, currencies, languages sql.NullString, validFrom time.Time) db.CreateCountryHistoryParams {
+	return db.CreateCountryHistoryParams{
+		CountryID:        sql.NullInt64{Int64: country.ID, Valid: country.ID != 0},
+		CountryName:      country.Name,
+		Capital:          country.Capital,
+		Region:           country.Region,
+		Population:       country.Population,
+		CurrencyCode:     country.CurrencyCode,
+		ExchangeRate:     country.ExchangeRate,
+		EstimatedGdp:     country.EstimatedGdp,
+		FlagUrl:          country.FlagUrl,
+		ValidFrom:        validFrom,
+		GdpStrategy:      country.GdpStrategy,
+		GdpMultiplier:    country.GdpMultiplier,
+		ReportedGdp:      country.ReportedGdp,
+		ReportedGdpYear:  country.ReportedGdpYear,
+		Alpha2Code:       country.Alpha2Code,
+		Alpha3Code:       country.Alpha3Code,
+		NumericCode:      country.NumericCode,
+		Subregion:        country.Subregion,
+		Area:             country.Area,
+		Latitude:         country.Latitude,
+		Longitude:        country.Longitude,
+		Timezones:        country.Timezones,
+		CallingCodes:     country.CallingCodes,
+		Borders:          country.Borders,
+		CapitalLatitude:  country.CapitalLatitude,
+		CapitalLongitude: country.CapitalLongitude,
+		Currencies:       currencies,
+		Languages:        languages,
+	}
+}
+
+// historyCountryID resolves a name or ISO code to the id a country's
+// history is kept under: the stored country's, or for one renamed or
+// deleted since, the id of the newest version recorded under that name or
+// code. Returns sql.ErrNoRows if neither knows the key.
+func (c *CountryService) historyCountryID(ctx context.Context, key string) (sql.NullInt64, error) {
+	country, err := findCountry(ctx, c.q, key)
+	if err == nil {
+		return sql.NullInt64{Int64: country.ID, Valid: true}, nil
+	}
+	if err != sql.ErrNoRows {
+		return sql.NullInt64{}, fmt.Errorf("could not resolve country: %w", err)
+	}
+	id, err := c.q.GetHistoryCountryID(ctx, key)
+	if err != nil && err != sql.ErrNoRows {
+		return sql.NullInt64{}, fmt.Errorf("could not resolve country history: %w", err)
+	}
+	return id, err
+}
+
+// function to get all countries as they were at a point in time
+func (c *CountryService) GetAllCountriesAsOf(asOf time.Time) ([]db.Country, error) {
+	ctx := context.Background()
+	versions, err := c.q.ListCountriesAsOf(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("could not get countries as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	countries := make([]db.Country, 0, len(versions))
+	for _, v := range versions {
+		countries = append(countries, HistoryToCountry(v))
+	}
+	return countries, nil
+}
+
+// function to get a country by name or ISO code as it was at a point in
+// time; a renamed country is found under its old name too
+func (c *CountryService) GetCountryByNameAsOf(name string, asOf time.Time) (db.CountryHistory, error) {
+	ctx := context.Background()
+	id, err := c.historyCountryID(ctx, name)
+	if err != nil {
+		return db.CountryHistory{}, err
+	}
+	version, err := c.q.GetCountryAsOf(ctx, db.GetCountryAsOfParams{CountryID: id, AsOf: asOf})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.CountryHistory{}, err
+		}
+		return db.CountryHistory{}, fmt.Errorf("could not get country as of %s: %w", asOf.Format(time.RFC3339), err)
+	}
+	return version, nil
+}
+
+// function to get every recorded version of a country, oldest first,
+// including those from before it was renamed
+func (c *CountryService) GetCountryHistory(name string) ([]db.CountryHistory, error) {
+	ctx := context.Background()
+	id, err := c.historyCountryID(ctx, name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions, err := c.q.ListCountryHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get country history: %w", err)
+	}
+	return versions, nil
+}
+
+// HistoryToCountry presents a history version as a countries row. The
+// version's start doubles as its last refresh time.
+func HistoryToCountry(v db.CountryHistory) db.Country {
+	return db.Country{
+		ID:               v.CountryID.Int64,
+		Name:             v.CountryName,
+		Capital:          v.Capital,
+		Region:           v.Region,
+		Population:       v.Population,
+		CurrencyCode:     v.CurrencyCode,
+		ExchangeRate:     v.ExchangeRate,
+		EstimatedGdp:     v.EstimatedGdp,
+		FlagUrl:          v.FlagUrl,
+		LastRefreshedAt:  sql.NullTime{Time: v.ValidFrom, Valid: true},
+		GdpStrategy:      v.GdpStrategy,
+		GdpMultiplier:    v.GdpMultiplier,
+		ReportedGdp:      v.ReportedGdp,
+		ReportedGdpYear:  v.ReportedGdpYear,
+		Alpha2Code:       v.Alpha2Code,
+		Alpha3Code:       v.Alpha3Code,
+		NumericCode:      v.NumericCode,
+		Subregion:        v.Subregion,
+		Area:             v.Area,
+		Latitude:         v.Latitude,
+		Longitude:        v.Longitude,
+		Timezones:        v.Timezones,
+		CallingCodes:     v.CallingCodes,
+		Borders:          v.Borders,
+		CapitalLatitude:  v.CapitalLatitude,
+		CapitalLongitude: v.CapitalLongitude,
+	}
+}
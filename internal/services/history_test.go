@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func TestHistorySnapshotKeepsTheFullRow(t *testing.T) {
+	validFrom := time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)
+	country := db.Country{
+		ID:               7,
+		Name:             "Eswatini",
+		Capital:          nullString("Mbabane"),
+		Region:           nullString("Africa"),
+		Population:       1160164,
+		CurrencyCode:     nullString("SZL"),
+		ExchangeRate:     nullString("18.000000"),
+		EstimatedGdp:     nullString("96680.33"),
+		FlagUrl:          nullString("https://flagcdn.com/sz.svg"),
+		LastRefreshedAt:  sql.NullTime{Time: validFrom, Valid: true},
+		GdpStrategy:      nullString("random"),
+		GdpMultiplier:    nullString("1500.0000"),
+		ReportedGdp:      nullString("4854000000.00"),
+		ReportedGdpYear:  sql.NullInt32{Int32: 2022, Valid: true},
+		Alpha2Code:       nullString("SZ"),
+		Alpha3Code:       nullString("SWZ"),
+		NumericCode:      nullString("748"),
+		Subregion:        nullString("Southern Africa"),
+		Area:             nullString("17364.00"),
+		Latitude:         nullString("-26.500000"),
+		Longitude:        nullString("31.500000"),
+		Timezones:        jsonList([]string{"UTC+02:00"}),
+		CallingCodes:     jsonList([]string{"+268"}),
+		Borders:          jsonList([]string{"MOZ", "ZAF"}),
+		CapitalLatitude:  nullString("-26.320000"),
+		CapitalLongitude: nullString("31.130000"),
+	}
+	currencies := jsonList([]string{"SZL", "ZAR"})
+	languages := jsonList([]string{"eng", "ssw"})
+
+	params := historySnapshot(country, currencies, languages, validFrom)
+	if params.Currencies != currencies || params.Languages != languages {
+		t.Errorf("lists = %v, %v", params.Currencies, params.Languages)
+	}
+	if !params.CountryID.Valid || params.CountryID.Int64 != 7 {
+		t.Errorf("CountryID = %v, want 7", params.CountryID)
+	}
+
+	// store the version as the table would and read it back
+	var version db.CountryHistory
+	src := reflect.ValueOf(params)
+	dst := reflect.ValueOf(&version).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		field := dst.FieldByName(src.Type().Field(i).Name)
+		if !field.IsValid() {
+			t.Fatalf("country_history has no column for %s", src.Type().Field(i).Name)
+		}
+		field.Set(src.Field(i))
+	}
+	if got := HistoryToCountry(version); !reflect.DeepEqual(got, country) {
+		t.Errorf("HistoryToCountry(historySnapshot(c)) =\n%+v\nwant\n%+v", got, country)
+	}
+
+	// every countries column but stale_since is versioned
+	versioned := reflect.TypeOf(version)
+	countryType := reflect.TypeOf(country)
+	for i := 0; i < countryType.NumField(); i++ {
+		name := countryType.Field(i).Name
+		switch name {
+		case "ID", "Name", "LastRefreshedAt", "StaleSince":
+			continue
+		}
+		if _, ok := versioned.FieldByName(name); !ok {
+			t.Errorf("country_history does not keep %s", name)
+		}
+	}
+}
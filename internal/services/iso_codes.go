@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// ErrCodeConflict is returned for a record whose ISO codes belong to another
+// stored country that it cannot simply rename
+var ErrCodeConflict = errors.New("iso code belongs to another country")
+
+// codeRename is a stored country that upstream now returns under a new
+// name with the same ISO codes
+type codeRename struct {
+	ID   int64
+	From string
+	To   string
+}
+
+// codeOwner is the country holding an ISO code: a stored row, or a row of
+// the current write when ID is zero
+type codeOwner struct {
+	ID   int64
+	Name string
+}
+
+// codeIndex finds countries by name and by ISO code. The codes are unique
+// keys of the countries table, so an upsert whose codes belong to another
+// row would update that row instead of its own, keeping the old name;
+// writes are checked against the index first.
+type codeIndex struct {
+	byName map[string]codeOwner
+	byCode map[string]codeOwner
+}
+
+type isoCode struct{ field, value string }
+
+func newCodeIndex(countries []db.Country) codeIndex {
+	ix := codeIndex{
+		byName: make(map[string]codeOwner, len(countries)),
+		byCode: make(map[string]codeOwner, 3*len(countries)),
+	}
+	for _, country := range countries {
+		ix.add(codeOwner{ID: country.ID, Name: country.Name},
+			storedCodes(country.Alpha2Code, country.Alpha3Code, country.NumericCode))
+	}
+	return ix
+}
+
+func storedCodes(alpha2, alpha3, numeric sql.NullString) []isoCode {
+	var codes []isoCode
+	for _, code := range []isoCode{
+		{"alpha2_code", alpha2.String},
+		{"alpha3_code", alpha3.String},
+		{"numeric_code", numeric.String},
+	} {
+		if v := strings.ToUpper(strings.TrimSpace(code.value)); v != "" {
+			codes = append(codes, isoCode{code.field, v})
+		}
+	}
+	return codes
+}
+
+func rowCodes(row db.UpsertCountryParams) []isoCode {
+	return storedCodes(row.Alpha2Code, row.Alpha3Code, row.NumericCode)
+}
+
+func (ix codeIndex) add(owner codeOwner, codes []isoCode) {
+	ix.byName[strings.ToLower(owner.Name)] = owner
+	for _, code := range codes {
+		ix.byCode[code.field+":"+code.value] = owner
+	}
+}
+
+// owner returns the country another name holds the row's codes under.
+// Codes spread over several countries, held by a row that is not stored
+// yet, or held while a stored country already has the row's name are a
+// conflict, since no single row can take the write.
+func (ix codeIndex) owner(row db.UpsertCountryParams) (*codeOwner, error) {
+	var found *codeOwner
+	for _, code := range rowCodes(row) {
+		o, ok := ix.byCode[code.field+":"+code.value]
+		if !ok || strings.EqualFold(o.Name, row.Name) {
+			continue
+		}
+		if o.ID == 0 {
+			return nil, fmt.Errorf("%w: %s %s is also used by %s", ErrCodeConflict, code.field, code.value, o.Name)
+		}
+		if _, taken := ix.byName[strings.ToLower(row.Name)]; taken {
+			return nil, fmt.Errorf("%w: %s %s is used by %s", ErrCodeConflict, code.field, code.value, o.Name)
+		}
+		if found != nil && found.ID != o.ID {
+			return nil, fmt.Errorf("%w: %s %s is used by %s, other codes by %s",
+				ErrCodeConflict, code.field, code.value, o.Name, found.Name)
+		}
+		found = &o
+	}
+	return found, nil
+}
+
+// planRenames checks the rows about to be upserted against the stored
+// countries. A row holding the codes of one stored country under a new name
+// renames that country, unless upstream still returns the old name as well;
+// rows whose codes clash in any other way are failed.
+func planRenames(stored []db.Country, rows []db.UpsertCountryParams) ([]codeRename, []RowFailure) {
+	ix := newCodeIndex(stored)
+	upstream := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		upstream[strings.ToLower(row.Name)] = true
+	}
+
+	var renames []codeRename
+	var failures []RowFailure
+	for _, row := range rows {
+		o, err := ix.owner(row)
+		if err == nil && o != nil && upstream[strings.ToLower(o.Name)] {
+			err = fmt.Errorf("%w: %s holds its codes and is still returned upstream", ErrCodeConflict, o.Name)
+		}
+		if err != nil {
+			failures = append(failures, RowFailure{Name: row.Name, Err: err})
+			continue
+		}
+		id := int64(0)
+		if o != nil {
+			renames = append(renames, codeRename{ID: o.ID, From: o.Name, To: row.Name})
+			delete(ix.byName, strings.ToLower(o.Name))
+			id = o.ID
+		} else if existing, ok := ix.byName[strings.ToLower(row.Name)]; ok {
+			id = existing.ID
+		}
+		ix.add(codeOwner{ID: id, Name: row.Name}, rowCodes(row))
+	}
+	return renames, failures
+}
+
+// renameCountries gives renamed countries their new name, so the upsert
+// that follows matches them by name rather than through a code
+func renameCountries(ctx context.Context, qtx *db.Queries, renames []codeRename) error {
+	for _, r := range renames {
+		log.Printf("Renaming country %s to %s", r.From, r.To)
+		if err := qtx.RenameCountry(ctx, db.RenameCountryParams{Name: r.To, ID: r.ID}); err != nil {
+			return fmt.Errorf("could not rename %s to %s: %w", r.From, r.To, err)
+		}
+	}
+	return nil
+}
+
+// withoutFailures drops the rows named in failures
+func withoutFailures(rows []db.UpsertCountryParams, failures []RowFailure) []db.UpsertCountryParams {
+	if len(failures) == 0 {
+		return rows
+	}
+	failed := failedNames(failures)
+	kept := make([]db.UpsertCountryParams, 0, len(rows))
+	for _, row := range rows {
+		if !failed[row.Name] {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func codedCountry(id int64, name, alpha2, alpha3, numeric string) db.Country {
+	return db.Country{
+		ID: id, Name: name, Population: 1000,
+		Alpha2Code: nullString(alpha2), Alpha3Code: nullString(alpha3), NumericCode: nullString(numeric),
+	}
+}
+
+func codedRow(name, alpha2, alpha3, numeric string) db.UpsertCountryParams {
+	return db.UpsertCountryParams{
+		Name: name, Population: 1000,
+		Alpha2Code: nullString(alpha2), Alpha3Code: nullString(alpha3), NumericCode: nullString(numeric),
+	}
+}
+
+func TestPlanRenames(t *testing.T) {
+	stored := []db.Country{
+		codedCountry(1, "Swaziland", "SZ", "SWZ", "748"),
+		codedCountry(2, "Ghana", "GH", "GHA", "288"),
+		codedCountry(3, "Togo", "TG", "TGO", "768"),
+	}
+	tests := []struct {
+		name        string
+		rows        []db.UpsertCountryParams
+		wantRenames []codeRename
+		wantFailed  []string
+	}{
+		{
+			name: "same names",
+			rows: []db.UpsertCountryParams{codedRow("Ghana", "GH", "GHA", "288"), codedRow("togo", "TG", "TGO", "768")},
+		},
+		{
+			name: "new country with new codes",
+			rows: []db.UpsertCountryParams{codedRow("Benin", "BJ", "BEN", "204")},
+		},
+		{
+			name:        "renamed upstream",
+			rows:        []db.UpsertCountryParams{codedRow("Eswatini", "SZ", "SWZ", "748")},
+			wantRenames: []codeRename{{ID: 1, From: "Swaziland", To: "Eswatini"}},
+		},
+		{
+			name:        "renamed with one code left out",
+			rows:        []db.UpsertCountryParams{codedRow("Eswatini", "", "SWZ", "")},
+			wantRenames: []codeRename{{ID: 1, From: "Swaziland", To: "Eswatini"}},
+		},
+		{
+			name:       "codes of a country that keeps its name",
+			rows:       []db.UpsertCountryParams{codedRow("Ghana", "GH", "GHA", "288"), codedRow("Gold Coast", "GH", "GCO", "")},
+			wantFailed: []string{"Gold Coast"},
+		},
+		{
+			name:       "codes of another stored country",
+			rows:       []db.UpsertCountryParams{codedRow("Togo", "GH", "TGO", "768")},
+			wantFailed: []string{"Togo"},
+		},
+		{
+			name:       "codes of two stored countries",
+			rows:       []db.UpsertCountryParams{codedRow("Ghana-Togo", "GH", "TGO", "")},
+			wantFailed: []string{"Ghana-Togo"},
+		},
+		{
+			name:       "codes shared by two new rows",
+			rows:       []db.UpsertCountryParams{codedRow("Benin", "BJ", "BEN", "204"), codedRow("Dahomey", "BJ", "DAH", "")},
+			wantFailed: []string{"Dahomey"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renames, failures := planRenames(stored, tt.rows)
+			if !reflect.DeepEqual(renames, tt.wantRenames) {
+				t.Errorf("renames = %+v, want %+v", renames, tt.wantRenames)
+			}
+			var failed []string
+			for _, f := range failures {
+				failed = append(failed, f.Name)
+				if !errors.Is(f.Err, ErrCodeConflict) {
+					t.Errorf("failure %s: err = %v, want %v", f.Name, f.Err, ErrCodeConflict)
+				}
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("failed = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestDiffCountriesRename(t *testing.T) {
+	previous := []db.Country{codedCountry(1, "Swaziland", "SZ", "SWZ", "748")}
+	rows := []db.UpsertCountryParams{codedRow("Eswatini", "SZ", "SWZ", "748")}
+	rows[0].Population = 2000
+
+	report := diffCountries(previous, rows, nil, nil, languageSets{})
+	if report.Added != 0 || report.Removed != 0 || report.Modified != 1 {
+		t.Fatalf("added, removed, modified = %d, %d, %d, want 0, 0, 1", report.Added, report.Removed, report.Modified)
+	}
+	change := report.Changes[0]
+	if change.ID != 1 || change.Name != "Eswatini" {
+		t.Errorf("change = %+v", change)
+	}
+	if len(change.Fields) != 2 || change.Fields[0].Field != "name" || change.Fields[0].Old.String != "Swaziland" ||
+		change.Fields[1].Field != "population" {
+		t.Errorf("fields = %+v, want name and population", change.Fields)
+	}
+	if ids := report.RemovedIDs(); len(ids) != 0 {
+		t.Errorf("RemovedIDs = %v, a renamed country must not be reconciled away", ids)
+	}
+}
+
+func TestInScopeKeepsRenamed(t *testing.T) {
+	previous := []db.Country{
+		codedCountry(1, "Swaziland", "SZ", "SWZ", "748"),
+		codedCountry(2, "Ghana", "GH", "GHA", "288"),
+	}
+	rows := []db.UpsertCountryParams{codedRow("Eswatini", "SZ", "SWZ", "748")}
+	kept := inScope(previous, rows, nil)
+	if len(kept) != 1 || kept[0].ID != 1 {
+		t.Errorf("inScope = %+v, want only Swaziland", kept)
+	}
+}
+
+func TestWithoutFailures(t *testing.T) {
+	rows := []db.UpsertCountryParams{codedRow("Ghana", "", "", ""), codedRow("Togo", "", "", "")}
+	if got := withoutFailures(rows, nil); len(got) != 2 {
+		t.Errorf("withoutFailures(nil) dropped rows: %+v", got)
+	}
+	got := withoutFailures(rows, []RowFailure{{Name: "Ghana"}})
+	if len(got) != 1 || got[0].Name != "Togo" {
+		t.Errorf("withoutFailures = %+v, want Togo", got)
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+	"github.com/franzego/stage02/models"
+)
+
+// writeLanguages stores every language of the saved countries and links
+// them through country_languages, replacing the previous links
+func (c *CountryService) writeLanguages(ctx context.Context, qtx *db.Queries, processed []models.ProcessedCountry, failed map[string]bool, ids map[string]int64) error {
+	languages := map[string]db.UpsertLanguageParams{}
+	var countryIDs []int64
+	links := map[int64][]db.CreateCountryLanguageParams{}
+	for _, p := range processed {
+		id, ok := ids[strings.ToLower(p.Name)]
+		if !ok || failed[p.Name] {
+			continue
+		}
+		countryIDs = append(countryIDs, id)
+		for i, lang := range p.Languages {
+			row := db.UpsertLanguageParams{
+				Code:    lang.Iso6392,
+				Iso6391: sql.NullString{String: lang.Iso6391, Valid: lang.Iso6391 != ""},
+				Name:    sql.NullString{String: lang.Name, Valid: lang.Name != ""},
+			}
+			// keep a two-letter code another country's record supplied
+			if prev, ok := languages[lang.Iso6392]; ok && !row.Iso6391.Valid {
+				row.Iso6391 = prev.Iso6391
+			}
+			languages[lang.Iso6392] = row
+			links[id] = append(links[id], db.CreateCountryLanguageParams{
+				CountryID:    id,
+				LanguageCode: lang.Iso6392,
+				Position:     int32(i),
+			})
+		}
+	}
+
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	languageRows := make([]db.UpsertLanguageParams, 0, len(codes))
+	for _, code := range codes {
+		languageRows = append(languageRows, languages[code])
+	}
+	for start := 0; start < len(languageRows); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(languageRows))
+		if err := qtx.UpsertLanguages(ctx, languageRows[start:end]); err != nil {
+			return fmt.Errorf("could not save languages: %w", err)
+		}
+	}
+
+	for start := 0; start < len(countryIDs); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(countryIDs))
+		var batch []db.CreateCountryLanguageParams
+		for _, id := range countryIDs[start:end] {
+			batch = append(batch, links[id]...)
+		}
+		if err := qtx.ReplaceCountryLanguages(ctx, countryIDs[start:end], batch); err != nil {
+			return fmt.Errorf("could not save country languages: %w", err)
+		}
+	}
+	return nil
+}
+
+// storedLanguages loads the language codes of every stored country by id,
+// as the JSON lists a refresh compares
+func storedLanguages(ctx context.Context, qtx *db.Queries) (map[int64]sql.NullString, error) {
+	rows, err := qtx.ListLanguageCodesByCountry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load country languages: %w", err)
+	}
+	codes := make(map[int64][]string)
+	for _, row := range rows {
+		codes[row.CountryID] = append(codes[row.CountryID], row.LanguageCode)
+	}
+	languages := make(map[int64]sql.NullString, len(codes))
+	for id, list := range codes {
+		languages[id] = jsonList(list)
+	}
+	return languages, nil
+}
+
+// processedLanguages lists the language codes of processed countries by
+// lower-cased name, in the form storedLanguages returns
+func processedLanguages(processed []models.ProcessedCountry) map[string]sql.NullString {
+	languages := make(map[string]sql.NullString, len(processed))
+	for _, p := range processed {
+		codes := make([]string, 0, len(p.Languages))
+		for _, lang := range p.Languages {
+			codes = append(codes, lang.Iso6392)
+		}
+		languages[strings.ToLower(p.Name)] = jsonList(codes)
+	}
+	return languages
+}
+
+// function to get every language of a country, in upstream order
+func (c *CountryService) GetCountryLanguages(countryID int64) ([]db.Language, error) {
+	ctx := context.Background()
+	languages, err := c.q.ListCountryLanguages(ctx, countryID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get country languages: %w", err)
+	}
+	return languages, nil
+}
+
+// function to get the ids of countries that speak a language, given as a
+// two- or three-letter code or an English name
+func (c *CountryService) CountryIDsWithLanguage(language string) (map[int64]bool, error) {
+	ctx := context.Background()
+	ids, err := c.q.ListCountryIDsByLanguage(ctx, strings.ToLower(strings.TrimSpace(language)))
+	if err != nil {
+		return nil, fmt.Errorf("could not get countries by language: %w", err)
+	}
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
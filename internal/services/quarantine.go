@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/franzego/stage02/db/sqlc"
+	"github.com/franzego/stage02/models"
+)
+
+// Quarantine states stored in quarantined_countries.status
+const (
+	QuarantinePending  = "pending"
+	QuarantineAccepted = "accepted"
+	// QuarantineAll lists entries in every state
+	QuarantineAll = "all"
+)
+
+var (
+	// ErrUnknownQuarantineStatus is returned when listing by an unknown state
+	ErrUnknownQuarantineStatus = errors.New("unknown quarantine status")
+	// ErrAlreadyAccepted is returned when accepting an entry twice
+	ErrAlreadyAccepted = errors.New("quarantined country was already accepted")
+	// ErrCannotAccept is returned for entries that cannot be stored at all,
+	// whatever the rules say
+	ErrCannotAccept = errors.New("quarantined country cannot be stored")
+)
+
+// heldCountry is an upstream record that failed validation
+type heldCountry struct {
+	country models.CountryData
+	reasons []string
+	payload string
+}
+
+// SetValidationRules overrides the rules upstream records must pass
+func (c *CountryService) SetValidationRules(rules ValidationRules) {
+	c.rules = rules
+}
+
+// screenCountries splits upstream records into the ones to store and the
+// ones to quarantine. A record identical to one that was force-accepted
+// before skips the rules, so accepting it sticks across refreshes.
+func (c *CountryService) screenCountries(ctx context.Context, countries []models.CountryData) ([]models.CountryData, []heldCountry, error) {
+	accepted, err := c.q.ListAcceptedPayloads(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not load accepted quarantine entries: %w", err)
+	}
+	trusted := make(map[string]bool, len(accepted))
+	for _, a := range accepted {
+		trusted[a.Payload] = true
+	}
+
+	valid := make([]models.CountryData, 0, len(countries))
+	var held []heldCountry
+	claimed := map[string]string{}
+	for _, country := range countries {
+		reasons := c.rules.Validate(country)
+		reasons = append(reasons, claimCodes(claimed, country)...)
+		if len(reasons) == 0 {
+			valid = append(valid, country)
+			continue
+		}
+		payload, err := json.Marshal(country)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not encode %s for quarantine: %w", country.Name, err)
+		}
+		if trusted[string(payload)] {
+			valid = append(valid, country)
+			continue
+		}
+		held = append(held, heldCountry{country: country, reasons: reasons, payload: string(payload)})
+	}
+	return valid, held, nil
+}
+
+// claimCodes records the ISO codes of a record and reports the ones an
+// earlier record of the same payload already uses. The codes are unique in
+// the countries table, so a duplicate would overwrite the other country.
+func claimCodes(claimed map[string]string, country models.CountryData) []string {
+	var reasons []string
+	for _, code := range []struct{ field, value string }{
+		{"alpha2Code", country.Alpha2Code},
+		{"alpha3Code", country.Alpha3Code},
+		{"numericCode", country.NumericCode},
+	} {
+		v := strings.ToUpper(strings.TrimSpace(code.value))
+		if v == "" {
+			continue
+		}
+		key := code.field + ":" + v
+		if owner, ok := claimed[key]; ok && owner != country.Name {
+			reasons = append(reasons, fmt.Sprintf("%s %s is already used by %s", code.field, v, owner))
+			continue
+		}
+		claimed[key] = country.Name
+	}
+	return reasons
+}
+
+// heldNames lists the names of quarantined records
+func heldNames(held []heldCountry) []string {
+	names := make([]string, 0, len(held))
+	for _, h := range held {
+		names = append(names, h.country.Name)
+	}
+	return names
+}
+
+// writeQuarantine stores the held records, replacing any pending entry for
+// the same name so repeated refreshes do not pile up duplicates
+func writeQuarantine(ctx context.Context, qtx *db.Queries, jobID int64, held []heldCountry) error {
+	for _, h := range held {
+		name := h.country.Name
+		if r := []rune(name); len(r) > 255 {
+			name = string(r[:255])
+		}
+		if err := qtx.DeletePendingQuarantine(ctx, name); err != nil {
+			return fmt.Errorf("could not replace quarantine entry for %s: %w", name, err)
+		}
+		if err := qtx.CreateQuarantinedCountry(ctx, db.CreateQuarantinedCountryParams{
+			JobID:       sql.NullInt64{Int64: jobID, Valid: jobID != 0},
+			CountryName: name,
+			Reason:      strings.Join(h.reasons, "; "),
+			Payload:     h.payload,
+		}); err != nil {
+			return fmt.Errorf("could not quarantine %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ListQuarantine returns quarantine entries in a state, or all of them
+func (c *CountryService) ListQuarantine(ctx context.Context, status string) ([]db.QuarantinedCountry, error) {
+	var entries []db.QuarantinedCountry
+	var err error
+	switch status {
+	case QuarantineAll:
+		entries, err = c.q.ListAllQuarantinedCountries(ctx)
+	case QuarantinePending, QuarantineAccepted:
+		entries, err = c.q.ListQuarantinedCountries(ctx, status)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownQuarantineStatus, status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list quarantined countries: %w", err)
+	}
+	return entries, nil
+}
+
+// AcceptQuarantined stores a quarantined record as is, using the newest
+// stored exchange rates, and marks the entry accepted. Returns sql.ErrNoRows
+// if the entry does not exist, and ErrCodeConflict if another stored
+// country holds one of its ISO codes: accepting it would overwrite or
+// rename that country.
+func (c *CountryService) AcceptQuarantined(ctx context.Context, id int64) (db.Country, error) {
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return db.Country{}, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := c.q.WithTx(tx)
+
+	entry, err := qtx.GetQuarantinedCountry(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Country{}, err
+		}
+		return db.Country{}, fmt.Errorf("could not get quarantined country: %w", err)
+	}
+	if entry.Status == QuarantineAccepted {
+		return db.Country{}, ErrAlreadyAccepted
+	}
+	var country models.CountryData
+	if err := json.Unmarshal([]byte(entry.Payload), &country); err != nil {
+		return db.Country{}, fmt.Errorf("could not decode quarantined country: %w", err)
+	}
+	if strings.TrimSpace(country.Name) == "" {
+		return db.Country{}, fmt.Errorf("%w: it has no name", ErrCannotAccept)
+	}
+	if len([]rune(country.Name)) > 255 {
+		return db.Country{}, fmt.Errorf("%w: its name does not fit the countries table", ErrCannotAccept)
+	}
+	if reasons := codeShapeReasons(country); len(reasons) > 0 {
+		return db.Country{}, fmt.Errorf("%w: %s", ErrCannotAccept, strings.Join(reasons, "; "))
+	}
+
+	var previous []db.Country
+	existing, err := qtx.GetCountryByName(ctx, country.Name)
+	if err == nil {
+		previous = append(previous, existing)
+	} else if err != sql.ErrNoRows {
+		return db.Country{}, fmt.Errorf("could not load current country: %w", err)
+	}
+
+	rates, _, err := latestRates(ctx, qtx)
+	if err != nil {
+		return db.Country{}, err
+	}
+	processed := c.processCountry(country, rates.Rates)
+	row := toUpsertParams(processed)
+	current, err := qtx.GetAllCountries(ctx)
+	if err != nil {
+		return db.Country{}, fmt.Errorf("could not load current countries: %w", err)
+	}
+	if o, err := newCodeIndex(current).owner(row); err != nil {
+		return db.Country{}, err
+	} else if o != nil {
+		return db.Country{}, fmt.Errorf("%w: its codes are used by %s", ErrCodeConflict, o.Name)
+	}
+	languages := languageSets{after: processedLanguages([]models.ProcessedCountry{processed})}
+	if languages.before, err = storedLanguages(ctx, qtx); err != nil {
+		return db.Country{}, err
+	}
+	if err := qtx.UpsertCountry(ctx, row); err != nil {
+		return db.Country{}, fmt.Errorf("could not store %s: %w", country.Name, err)
+	}
+
+	ids, err := countryIDs(ctx, qtx)
+	if err != nil {
+		return db.Country{}, err
+	}
+	if err := c.writeCurrencies(ctx, qtx, []models.ProcessedCountry{processed}, nil, ids); err != nil {
+		return db.Country{}, err
+	}
+	if err := c.writeLanguages(ctx, qtx, []models.ProcessedCountry{processed}, nil, ids); err != nil {
+		return db.Country{}, err
+	}
+	rows := []db.UpsertCountryParams{row}
+	report := diffCountries(previous, rows, nil, nil, languages)
+	if err := c.writeHistory(ctx, qtx, report, ReconcileNone); err != nil {
+		return db.Country{}, err
+	}
+	if err := qtx.AcceptQuarantinedCountry(ctx, id); err != nil {
+		return db.Country{}, fmt.Errorf("could not mark quarantine entry accepted: %w", err)
+	}
+
+	stored, err := qtx.GetCountryByName(ctx, country.Name)
+	if err != nil {
+		return db.Country{}, fmt.Errorf("could not load stored country: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return db.Country{}, fmt.Errorf("could not commit accepted country: %w", err)
+	}
+	return stored, nil
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	db "github.com/franzego/stage02/db/sqlc"
+	"github.com/franzego/stage02/models"
+)
+
+// Rate provider names, recorded on refresh jobs
+const (
+	ProviderERAPI       = "er-api"
+	ProviderFrankfurter = "frankfurter"
+	ProviderFile        = "file"
+	// ProviderCache marks rates reused from the exchange_rates table
+	ProviderCache = "cache"
+)
+
+// DefaultMaxRateDeviation rejects a rate set whose typical currency moved
+// by more than half against the last known set
+const DefaultMaxRateDeviation = 0.5
+
+// ErrRatesDeviate is returned for a rate set that fails the sanity check
+var ErrRatesDeviate = errors.New("exchange rates deviate too far from the last known set")
+
+// RateProvider is one named entry of a RateFailover
+type RateProvider struct {
+	Name   string
+	Source RateSource
+}
+
+// RateFailover tries its providers in order and returns the first rate set
+// that passes a sanity check against the newest stored set. Fallback
+// providers may quote fewer currencies than the primary; the missing ones
+// keep their last stored rate for the countries, without being stored again
+// under the new fetch time.
+type RateFailover struct {
+	q            *db.Queries
+	providers    []RateProvider
+	maxDeviation float64
+}
+
+func NewRateFailover(queries *db.Queries, providers ...RateProvider) *RateFailover {
+	return &RateFailover{
+		q:            queries,
+		providers:    providers,
+		maxDeviation: DefaultMaxRateDeviation,
+	}
+}
+
+// WithMaxDeviation sets how far, as a fraction, the median currency may move
+// from the last known set; 0 disables the check
+func (f *RateFailover) WithMaxDeviation(max float64) *RateFailover {
+	f.maxDeviation = max
+	return f
+}
+
+// Providers lists the provider names in the order they are tried
+func (f *RateFailover) Providers() []string {
+	names := make([]string, 0, len(f.providers))
+	for _, p := range f.providers {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Breakers reports the circuit breakers of every provider that has them
+func (f *RateFailover) Breakers() []BreakerSnapshot {
+	var snapshots []BreakerSnapshot
+	for _, p := range f.providers {
+		if r, ok := p.Source.(breakerReporter); ok {
+			snapshots = append(snapshots, r.Breakers()...)
+		}
+	}
+	return snapshots
+}
+
+func (f *RateFailover) FetchExchangeRate(ctx context.Context) (models.RateSet, error) {
+	last, haveLast, err := latestRates(ctx, f.q)
+	if err != nil {
+		// without a baseline the sanity check is skipped, not the refresh
+		log.Printf("rate failover: %v", err)
+		haveLast = false
+	}
+
+	var errs []error
+	for i, p := range f.providers {
+		set, err := p.Source.FetchExchangeRate(ctx)
+		if err == nil && haveLast {
+			err = f.check(set, last)
+		}
+		if err != nil {
+			log.Printf("rate provider %s failed: %v", p.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+		set.Provider = p.Name
+		if i > 0 && haveLast {
+			if n := carryForward(&set, last); n > 0 {
+				log.Printf("rate provider %s: kept the last stored rate for %d currencies it does not quote", p.Name, n)
+			}
+		}
+		return set, nil
+	}
+	return models.RateSet{}, fmt.Errorf("all rate providers failed: %w", errors.Join(errs...))
+}
+
+// carryForward copies into set the rates of last for currencies set does
+// not quote, converting them when the bases differ, marks them as carried
+// and returns how many were copied
+func carryForward(set *models.RateSet, last models.RateSet) int {
+	scale := 1.0
+	if set.Base != last.Base {
+		// last quotes the new base too, so its rates can be rebased
+		base, ok := last.Rates[set.Base]
+		if !ok || base <= 0 {
+			return 0
+		}
+		scale = 1 / base
+	}
+	if set.Rates == nil {
+		set.Rates = make(map[string]float64, len(last.Rates))
+	}
+	if set.Carried == nil {
+		set.Carried = make(map[string]bool)
+	}
+	n := 0
+	for code, rate := range last.Rates {
+		if _, ok := set.Rates[code]; ok || rate <= 0 {
+			continue
+		}
+		set.Rates[code] = rate * scale
+		set.Carried[code] = true
+		n++
+	}
+	return n
+}
+
+// check compares the currencies both sets quote against the same base.
+// The median relative change is used so one currency in crisis does not
+// disqualify a provider.
+func (f *RateFailover) check(set, last models.RateSet) error {
+	if f.maxDeviation <= 0 || set.Base != last.Base {
+		return nil
+	}
+	var deviations []float64
+	for code, old := range last.Rates {
+		rate, ok := set.Rates[code]
+		if !ok || old <= 0 || code == set.Base {
+			continue
+		}
+		deviations = append(deviations, math.Abs(rate-old)/old)
+	}
+	if len(deviations) == 0 {
+		return nil
+	}
+	sort.Float64s(deviations)
+	median := deviations[len(deviations)/2]
+	if median > f.maxDeviation {
+		return fmt.Errorf("%w: median change %.0f%% over %d currencies, limit %.0f%%",
+			ErrRatesDeviate, median*100, len(deviations), f.maxDeviation*100)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/franzego/stage02/models"
+)
+
+func TestCarryForward(t *testing.T) {
+	last := models.RateSet{Base: "USD", Rates: map[string]float64{"USD": 1, "EUR": 0.9, "GHS": 15, "XOF": 600, "BAD": 0}}
+	tests := []struct {
+		name  string
+		set   models.RateSet
+		want  map[string]float64
+		count int
+	}{
+		{
+			name:  "missing currencies keep their last rate",
+			set:   models.RateSet{Base: "USD", Rates: map[string]float64{"USD": 1, "EUR": 0.92}},
+			want:  map[string]float64{"USD": 1, "EUR": 0.92, "GHS": 15, "XOF": 600},
+			count: 2,
+		},
+		{
+			name:  "nothing missing",
+			set:   models.RateSet{Base: "USD", Rates: map[string]float64{"USD": 1, "EUR": 0.92, "GHS": 16, "XOF": 610}},
+			want:  map[string]float64{"USD": 1, "EUR": 0.92, "GHS": 16, "XOF": 610},
+			count: 0,
+		},
+		{
+			name:  "rebased onto the new base",
+			set:   models.RateSet{Base: "EUR", Rates: map[string]float64{"EUR": 1, "USD": 1.1}},
+			want:  map[string]float64{"EUR": 1, "USD": 1.1, "GHS": 15 / 0.9, "XOF": 600 / 0.9},
+			count: 2,
+		},
+		{
+			name:  "base unknown to the last set",
+			set:   models.RateSet{Base: "CHF", Rates: map[string]float64{"CHF": 1}},
+			want:  map[string]float64{"CHF": 1},
+			count: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := carryForward(&tt.set, last)
+			if n != tt.count || len(tt.set.Carried) != tt.count {
+				t.Errorf("carried %d rates, marked %v, want %d", n, tt.set.Carried, tt.count)
+			}
+			if len(tt.set.Rates) != len(tt.want) {
+				t.Fatalf("rates = %v, want %v", tt.set.Rates, tt.want)
+			}
+			for code, want := range tt.want {
+				if got := tt.set.Rates[code]; math.Abs(got-want) > 1e-9 {
+					t.Errorf("rate %s = %v, want %v", code, got, want)
+				}
+			}
+		})
+	}
+
+	empty := models.RateSet{Base: "USD"}
+	carryForward(&empty, last)
+	if len(empty.Rates) != 4 {
+		t.Errorf("a set without rates got %v", empty.Rates)
+	}
+}
+
+func TestRateFailoverCheck(t *testing.T) {
+	last := models.RateSet{Base: "USD", Rates: map[string]float64{"USD": 1, "EUR": 1, "GHS": 10, "NGN": 100}}
+	tests := []struct {
+		name    string
+		set     models.RateSet
+		wantErr error
+	}{
+		{"close to the last set", models.RateSet{Base: "USD", Rates: map[string]float64{"EUR": 1.1, "GHS": 11, "NGN": 120}}, nil},
+		{"one currency in crisis", models.RateSet{Base: "USD", Rates: map[string]float64{"EUR": 1, "GHS": 10, "NGN": 1000}}, nil},
+		{"most currencies moved", models.RateSet{Base: "USD", Rates: map[string]float64{"EUR": 2, "GHS": 20, "NGN": 100}}, ErrRatesDeviate},
+		{"other base is not compared", models.RateSet{Base: "EUR", Rates: map[string]float64{"GHS": 99}}, nil},
+		{"no shared currencies", models.RateSet{Base: "USD", Rates: map[string]float64{"JPY": 150}}, nil},
+	}
+	f := NewRateFailover(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := f.check(tt.set, last); !errors.Is(err, tt.wantErr) {
+				t.Errorf("check = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := f.WithMaxDeviation(0).check(models.RateSet{Base: "USD", Rates: map[string]float64{"EUR": 50}}, last); err != nil {
+		t.Errorf("disabled check = %v", err)
+	}
+	if got := NewRateFailover(nil, RateProvider{Name: "a"}, RateProvider{Name: "b"}).Providers(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Providers = %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+	"github.com/franzego/stage02/models"
+)
+
+// Downsampling intervals for a rate series
+const (
+	IntervalRaw    = "raw"
+	IntervalDaily  = "daily"
+	IntervalWeekly = "weekly"
+)
+
+// ErrUnknownInterval is returned for an unsupported downsampling interval
+var ErrUnknownInterval = errors.New("unknown interval")
+
+// RatePoint is one value in an exchange rate series
+type RatePoint struct {
+	At   time.Time
+	Rate float64
+}
+
+// cachedRates returns the newest stored rate set when it is younger than
+// the policy's RatesMaxAge. ok is false when there is none that fresh.
+func (c *CountryService) cachedRates(ctx context.Context) (models.RateSet, bool, error) {
+	set, ok, err := latestRates(ctx, c.q)
+	if err != nil || !ok || time.Since(set.FetchedAt) > c.policy.RatesMaxAge {
+		return models.RateSet{}, false, err
+	}
+	return set, true, nil
+}
+
+// latestRates loads the newest stored rate set, however old it is
+func latestRates(ctx context.Context, q *db.Queries) (models.RateSet, bool, error) {
+	rows, err := q.ListLatestExchangeRates(ctx)
+	if err != nil {
+		return models.RateSet{}, false, fmt.Errorf("could not load stored exchange rates: %w", err)
+	}
+	if len(rows) == 0 {
+		return models.RateSet{}, false, nil
+	}
+
+	set := models.RateSet{
+		Base:      rows[0].BaseCode,
+		Rates:     make(map[string]float64, len(rows)),
+		FetchedAt: rows[0].FetchedAt,
+		Provider:  ProviderCache,
+	}
+	for _, row := range rows {
+		if row.BaseCode != set.Base {
+			continue
+		}
+		rate, err := strconv.ParseFloat(row.Rate, 64)
+		if err != nil {
+			return models.RateSet{}, false, fmt.Errorf("invalid stored rate for %s: %w", row.CurrencyCode, err)
+		}
+		set.Rates[row.CurrencyCode] = rate
+	}
+	return set, true, nil
+}
+
+// storeRates appends a fetched rate set to the exchange_rates history.
+// Sets already stored under the same timestamp are ignored, and so are
+// rates carried forward from an earlier set.
+func (c *CountryService) storeRates(ctx context.Context, qtx *db.Queries, set models.RateSet) error {
+	codes := make([]string, 0, len(set.Rates))
+	for code := range set.Rates {
+		if !set.Carried[code] {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	rows := make([]db.CreateExchangeRateParams, 0, len(codes))
+	for _, code := range codes {
+		rows = append(rows, db.CreateExchangeRateParams{
+			CurrencyCode: code,
+			BaseCode:     set.Base,
+			FetchedAt:    set.FetchedAt,
+			Rate:         strconv.FormatFloat(set.Rates[code], 'f', 6, 64),
+		})
+	}
+	for start := 0; start < len(rows); start += c.policy.BatchSize {
+		end := min(start+c.policy.BatchSize, len(rows))
+		if err := qtx.CreateExchangeRates(ctx, rows[start:end]); err != nil {
+			return fmt.Errorf("could not save exchange rates: %w", err)
+		}
+	}
+	return nil
+}
+
+// function to get the rate history of a currency
+// Daily and weekly intervals keep the last rate seen in each UTC day or
+// ISO week (starting Monday), stamped with the start of that bucket.
+func (c *CountryService) GetRateHistory(code, base string, from, to time.Time, interval string) ([]RatePoint, error) {
+	bucket, err := intervalBucket(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	rates, err := c.q.ListExchangeRates(ctx, db.ListExchangeRatesParams{
+		CurrencyCode: strings.ToUpper(code),
+		BaseCode:     strings.ToUpper(base),
+		FromTime:     from,
+		ToTime:       to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get exchange rates: %w", err)
+	}
+	return ratePoints(rates, bucket), nil
+}
+
+// intervalBucket returns the function that maps a time to the start of its
+// bucket, or nil for the raw series
+func intervalBucket(interval string) (func(time.Time) time.Time, error) {
+	switch interval {
+	case IntervalRaw, "":
+		return nil, nil
+	case IntervalDaily:
+		return startOfDay, nil
+	case IntervalWeekly:
+		return startOfWeek, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownInterval, interval)
+}
+
+// ratePoints turns time-ordered rows into a series, keeping the last rate
+// of each bucket when bucket is set
+func ratePoints(rates []db.ExchangeRate, bucket func(time.Time) time.Time) []RatePoint {
+	points := make([]RatePoint, 0, len(rates))
+	for _, r := range rates {
+		v, err := strconv.ParseFloat(r.Rate, 64)
+		if err != nil {
+			continue
+		}
+		at := r.FetchedAt.UTC()
+		if bucket != nil {
+			at = bucket(at)
+			// rows are ordered by time, so the last one in a bucket wins
+			if n := len(points); n > 0 && points[n-1].At.Equal(at) {
+				points[n-1].Rate = v
+				continue
+			}
+		}
+		points = append(points, RatePoint{At: at, Rate: v})
+	}
+	return points
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
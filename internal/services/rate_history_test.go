@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+func TestRateBuckets(t *testing.T) {
+	at := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name   string
+		bucket func(time.Time) time.Time
+		in     string
+		want   string
+	}{
+		{"day", startOfDay, "2024-09-04T17:45:10Z", "2024-09-04T00:00:00Z"},
+		{"day at midnight", startOfDay, "2024-09-04T00:00:00Z", "2024-09-04T00:00:00Z"},
+		{"day in UTC", startOfDay, "2024-09-04T01:30:00+03:00", "2024-09-03T00:00:00Z"},
+		{"monday stays", startOfWeek, "2024-09-02T08:00:00Z", "2024-09-02T00:00:00Z"},
+		{"midweek", startOfWeek, "2024-09-04T12:00:00Z", "2024-09-02T00:00:00Z"},
+		{"sunday ends the week", startOfWeek, "2024-09-08T23:59:59Z", "2024-09-02T00:00:00Z"},
+		{"week in UTC", startOfWeek, "2024-09-09T01:00:00+02:00", "2024-09-02T00:00:00Z"},
+		// ISO week 1 of 2025 starts on 30 December 2024
+		{"week across the year", startOfWeek, "2025-01-01T09:00:00Z", "2024-12-30T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.bucket(at(tt.in))
+			if want := at(tt.want); !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("bucket(%s) = %s, want %s", tt.in, got, want)
+			}
+		})
+	}
+}
+
+func TestRatePoints(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 9, d, h, 0, 0, 0, time.UTC) }
+	rates := []db.ExchangeRate{
+		{FetchedAt: day(2, 9), Rate: "1.10"},
+		{FetchedAt: day(2, 18), Rate: "1.20"},
+		{FetchedAt: day(3, 9), Rate: "not a rate"},
+		{FetchedAt: day(4, 9), Rate: "1.30"},
+		{FetchedAt: day(9, 9), Rate: "1.40"},
+	}
+
+	tests := []struct {
+		interval string
+		want     []RatePoint
+	}{
+		{"", []RatePoint{{day(2, 9), 1.10}, {day(2, 18), 1.20}, {day(4, 9), 1.30}, {day(9, 9), 1.40}}},
+		{IntervalRaw, []RatePoint{{day(2, 9), 1.10}, {day(2, 18), 1.20}, {day(4, 9), 1.30}, {day(9, 9), 1.40}}},
+		{IntervalDaily, []RatePoint{{day(2, 0), 1.20}, {day(4, 0), 1.30}, {day(9, 0), 1.40}}},
+		{IntervalWeekly, []RatePoint{{day(2, 0), 1.30}, {day(9, 0), 1.40}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			bucket, err := intervalBucket(tt.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ratePoints(rates, bucket); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ratePoints = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := intervalBucket("monthly"); !errors.Is(err, ErrUnknownInterval) {
+		t.Errorf("intervalBucket(monthly) = %v, want ErrUnknownInterval", err)
+	}
+}
@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	db "github.com/franzego/stage02/db/sqlc"
+)
+
+// Job states stored in refresh_jobs.status
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// ErrQueueFull is returned when too many refresh jobs are already waiting
+var ErrQueueFull = errors.New("refresh queue is full")
+
+// ErrRefreshInProgress is returned when a refresh is already queued or
+// running on this or another replica
+var ErrRefreshInProgress = errors.New("a refresh is already in progress")
+
+// RefreshInProgressError carries the job that is already in flight. Job is
+// zero when its row could not be found, e.g. while another caller has
+// claimed the slot but not created the row yet.
+type RefreshInProgressError struct {
+	Job db.RefreshJob
+}
+
+func (e *RefreshInProgressError) Error() string {
+	if e.Job.ID == 0 {
+		return ErrRefreshInProgress.Error()
+	}
+	return fmt.Sprintf("%s (job %d)", ErrRefreshInProgress, e.Job.ID)
+}
+
+func (e *RefreshInProgressError) Is(target error) bool {
+	return target == ErrRefreshInProgress
+}
+
+// RefreshJobService runs country refreshes in the background and records
+// their progress in the refresh_jobs table. The cluster lock makes sure only
+// one refresh runs across all replicas.
+type RefreshJobService struct {
+	q       *db.Queries
+	service *CountryService
+	lock    *ClusterLock
+	queue   chan queuedJob
+	active  atomic.Int32
+}
+
+type queuedJob struct {
+	id   int64
+	opts RefreshOptions
+	// release is set when the job was queued holding the cluster lock
+	release func()
+}
+
+func NewRefreshJobService(queries *db.Queries, service *CountryService, lock *ClusterLock) *RefreshJobService {
+	s := &RefreshJobService{
+		q:       queries,
+		service: service,
+		lock:    lock,
+		queue:   make(chan queuedJob, 8),
+	}
+	go s.worker()
+	return s
+}
+
+// RecoverInterrupted marks jobs left behind by a dead process as failed.
+// Other replicas may be mid-refresh, so it only runs while holding the
+// cluster lock: at that point no job can really be running, and a queued
+// job older than the lock timeout (plus a margin) has no worker waiting on
+// it. If the lock is busy, recovery is left to a later start.
+func (s *RefreshJobService) RecoverInterrupted(ctx context.Context) error {
+	release, err := s.lock.TryAcquire(ctx)
+	if err != nil {
+		if errors.Is(err, ErrLockTimeout) {
+			log.Println("refresh lock is held by another replica, skipping interrupted job recovery")
+			return nil
+		}
+		return fmt.Errorf("could not recover interrupted refresh jobs: %w", err)
+	}
+	defer release()
+
+	queuedBefore := time.Now().UTC().Add(-(s.lock.Timeout() + time.Minute))
+	if err := s.q.FailInterruptedRefreshJobs(ctx, queuedBefore); err != nil {
+		return fmt.Errorf("could not recover interrupted refresh jobs: %w", err)
+	}
+	return nil
+}
+
+// Enqueue persists a new queued job and hands it to the worker
+func (s *RefreshJobService) Enqueue(ctx context.Context, opts RefreshOptions) (db.RefreshJob, error) {
+	return s.enqueue(ctx, opts, false)
+}
+
+// TryEnqueue is Enqueue for callers that would rather skip than wait. It
+// takes the cluster lock before creating the job and hands it over, so when
+// another replica holds the lock it returns ErrRefreshInProgress at once
+// instead of queueing a job that fails after the lock timeout.
+func (s *RefreshJobService) TryEnqueue(ctx context.Context, opts RefreshOptions) (db.RefreshJob, error) {
+	return s.enqueue(ctx, opts, true)
+}
+
+func (s *RefreshJobService) enqueue(ctx context.Context, opts RefreshOptions, lock bool) (db.RefreshJob, error) {
+	source, err := s.service.ResolveSource(opts.Source)
+	if err != nil {
+		return db.RefreshJob{}, err
+	}
+	opts.Source = source
+	if err := ValidateReconcile(opts.Reconcile); err != nil {
+		return db.RefreshJob{}, err
+	}
+	if err := ValidateScope(opts); err != nil {
+		return db.RefreshJob{}, err
+	}
+	// claim the process's only slot before anything else, so two callers
+	// cannot both see it free and queue a refresh each; it is given back on
+	// every path that does not hand a job to the worker
+	if !s.active.CompareAndSwap(0, 1) {
+		return db.RefreshJob{}, s.inProgress(ctx)
+	}
+	queued := false
+	defer func() {
+		if !queued {
+			s.active.Add(-1)
+		}
+	}()
+	if err := s.checkInFlight(ctx); err != nil {
+		return db.RefreshJob{}, err
+	}
+	var release func()
+	if lock {
+		release, err = s.lock.TryAcquire(ctx)
+		if errors.Is(err, ErrLockTimeout) {
+			return db.RefreshJob{}, s.inProgress(ctx)
+		}
+		if err != nil {
+			return db.RefreshJob{}, err
+		}
+	}
+	job := queuedJob{opts: opts, release: release}
+
+	scope := opts.Scope()
+	res, err := s.q.CreateRefreshJob(ctx, db.CreateRefreshJobParams{
+		Source: source,
+		Scope:  sql.NullString{String: scope, Valid: scope != ""},
+	})
+	if err != nil {
+		job.unlock()
+		return db.RefreshJob{}, fmt.Errorf("could not create refresh job: %w", err)
+	}
+	if job.id, err = res.LastInsertId(); err != nil {
+		job.unlock()
+		return db.RefreshJob{}, fmt.Errorf("could not read refresh job id: %w", err)
+	}
+
+	select {
+	case s.queue <- job:
+		queued = true
+	default:
+		job.unlock()
+		s.finish(job.id, JobFailed, RefreshResult{}, ErrQueueFull)
+		return db.RefreshJob{}, ErrQueueFull
+	}
+	return s.q.GetRefreshJob(ctx, job.id)
+}
+
+// unlock releases the cluster lock if the job was queued holding it
+func (j queuedJob) unlock() {
+	if j.release != nil {
+		j.release()
+	}
+}
+
+// checkInFlight refuses a new job while another replica holds the refresh
+// lock; one queued or running here already holds the slot enqueue claims
+func (s *RefreshJobService) checkInFlight(ctx context.Context) error {
+	held, err := s.lock.Held(ctx)
+	if err != nil {
+		return err
+	}
+	if held {
+		return s.inProgress(ctx)
+	}
+	return nil
+}
+
+// inProgress builds the error for a refresh already in flight, with the
+// active job when it can be found
+func (s *RefreshJobService) inProgress(ctx context.Context) error {
+	job, err := s.q.GetActiveRefreshJob(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("could not get active refresh job: %w", err)
+	}
+	return &RefreshInProgressError{Job: job}
+}
+
+// Busy reports whether a refresh is queued or running in this process
+func (s *RefreshJobService) Busy() bool {
+	return s.active.Load() > 0
+}
+
+// GetJob returns a job by id, or sql.ErrNoRows if it does not exist
+func (s *RefreshJobService) GetJob(ctx context.Context, id int64) (db.RefreshJob, error) {
+	job, err := s.q.GetRefreshJob(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.RefreshJob{}, err
+		}
+		return db.RefreshJob{}, fmt.Errorf("could not get refresh job: %w", err)
+	}
+	return job, nil
+}
+
+// GetChanges returns the change report persisted for a job
+func (s *RefreshJobService) GetChanges(ctx context.Context, id int64) ([]db.RefreshChange, error) {
+	changes, err := s.q.ListRefreshChanges(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get refresh changes: %w", err)
+	}
+	return changes, nil
+}
+
+// worker runs queued jobs one at a time
+func (s *RefreshJobService) worker() {
+	for job := range s.queue {
+		s.run(job)
+		s.active.Add(-1)
+	}
+}
+
+func (s *RefreshJobService) run(job queuedJob) {
+	ctx := context.Background()
+	id, opts := job.id, job.opts
+	opts.JobID = id
+
+	// two replicas can pass checkInFlight at the same moment; the lock
+	// makes the second one wait and, past the timeout, give up
+	release := job.release
+	if release == nil {
+		var err error
+		if release, err = s.lock.Acquire(ctx); err != nil {
+			if errors.Is(err, ErrLockTimeout) {
+				err = fmt.Errorf("%w: %w", ErrRefreshInProgress, err)
+			}
+			s.finish(id, JobFailed, RefreshResult{}, err)
+			return
+		}
+	}
+	defer release()
+
+	if err := s.q.MarkRefreshJobRunning(ctx, id); err != nil {
+		log.Printf("refresh job %d: could not mark running: %v", id, err)
+	}
+
+	opts.Progress = func(processed, failed int) {
+		if err := s.q.UpdateRefreshJobProgress(ctx, db.UpdateRefreshJobProgressParams{
+			CountriesProcessed: int32(processed),
+			CountriesFailed:    int32(failed),
+			ID:                 id,
+		}); err != nil {
+			log.Printf("refresh job %d: could not record progress: %v", id, err)
+		}
+	}
+	result, err := s.service.RefreshCountries(ctx, opts)
+	if err != nil {
+		// nothing was committed, so there is nothing to report as changed
+		result.Changes = ChangeReport{}
+		s.finish(id, JobFailed, result, err)
+		return
+	}
+	if len(result.Failures) > 0 {
+		// tolerated bad rows are reported on an otherwise successful job
+		skipped := make([]string, 0, len(result.Failures))
+		for _, f := range result.Failures {
+			skipped = append(skipped, f.String())
+		}
+		s.finish(id, JobSucceeded, result, fmt.Errorf("skipped %d countries: %s",
+			len(skipped), strings.Join(skipped, "; ")))
+		return
+	}
+	s.finish(id, JobSucceeded, result, nil)
+}
+
+func (s *RefreshJobService) finish(id int64, status string, result RefreshResult, jobErr error) {
+	var errMsg sql.NullString
+	if jobErr != nil {
+		errMsg = sql.NullString{String: jobErr.Error(), Valid: true}
+		log.Printf("refresh job %d %s: %v", id, status, jobErr)
+	}
+	if err := s.q.FinishRefreshJob(context.Background(), db.FinishRefreshJobParams{
+		Status:               status,
+		CountriesProcessed:   int32(result.Processed),
+		CountriesFailed:      int32(result.Failed),
+		CountriesAdded:       int32(result.Changes.Added),
+		CountriesRemoved:     int32(result.Changes.Removed),
+		CountriesModified:    int32(result.Changes.Modified),
+		CountriesQuarantined: int32(result.Quarantined),
+		RateProvider:         sql.NullString{String: result.RateProvider, Valid: result.RateProvider != ""},
+		ErrorMessage:         errMsg,
+		ID:                   id,
+	}); err != nil {
+		log.Printf("refresh job %d: could not record result: %v", id, err)
+	}
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how upstream calls are retried
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// MaxRetryAfter caps how long a Retry-After header can make us wait
+	MaxRetryAfter time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:    3,
+	BaseDelay:     500 * time.Millisecond,
+	MaxDelay:      10 * time.Second,
+	MaxRetryAfter: time.Minute,
+}
+
+// backoff returns the full-jitter exponential delay before retry attempt n
+// (counting from 1)
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// parseRetryAfter understands both the delay-seconds and HTTP-date forms
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// getWithRetry performs a GET guarded by breaker, retrying network errors,
+// 429s and 5xx responses with backoff. It returns the final response (which
+// may still carry an error status) or the final error.
+func getWithRetry(ctx context.Context, client *http.Client, breaker *CircuitBreaker, policy RetryPolicy, url string) (*http.Response, error) {
+	return getWithRetryHeader(ctx, client, breaker, policy, url, nil)
+}
+
+// getWithRetryHeader is getWithRetry with extra request headers, such as the
+// validators of a conditional GET
+func getWithRetryHeader(ctx context.Context, client *http.Client, breaker *CircuitBreaker, policy RetryPolicy, url string, header http.Header) (*http.Response, error) {
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			breaker.Success()
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+
+		retryable := err != nil || isRetryableStatus(resp.StatusCode)
+		if !retryable {
+			breaker.Success()
+			return resp, nil
+		}
+		if attempt >= policy.MaxRetries || ctx.Err() != nil {
+			breaker.Failure()
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt + 1)
+		if resp != nil {
+			if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = max(delay, min(ra, policy.MaxRetryAfter))
+			}
+			// drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			breaker.Failure()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"absent", "", 0, false},
+		{"seconds", "120", 2 * time.Minute, true},
+		{"zero seconds", "0", 0, true},
+		{"negative seconds", "-5", 0, false},
+		{"http date", "Mon, 02 Sep 2024 10:00:30 GMT", 30 * time.Second, true},
+		{"date in the past", "Mon, 02 Sep 2024 09:59:00 GMT", 0, true},
+		{"garbage", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.header, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.header, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotModified:         false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	}
+	for code, want := range tests {
+		if got := isRetryableStatus(code); got != want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{70, time.Second}, // the shift overflows
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if d := p.backoff(tt.attempt); d < 0 || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s]", tt.attempt, d, tt.max)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("zero policy backoff = %s, want 0", d)
+	}
+}
+
+func TestGetWithRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		maxRetries  int
+		wantStatus  int
+		wantCalls   int32
+		wantBreaker string
+	}{
+		{"first call succeeds", []int{200}, 3, 200, 1, BreakerClosed},
+		{"retries 5xx and 429", []int{503, 429, 200}, 3, 200, 3, BreakerClosed},
+		{"4xx is not retried", []int{404}, 3, 404, 1, BreakerClosed},
+		{"gives up after max retries", []int{500, 500, 500}, 2, 500, 3, BreakerOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1)) - 1
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses)-1)])
+			}))
+			defer srv.Close()
+
+			breaker := NewCircuitBreaker("test", 1, time.Hour)
+			policy := RetryPolicy{MaxRetries: tt.maxRetries, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+			resp, err := getWithRetry(context.Background(), srv.Client(), breaker, policy, srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := breaker.Snapshot().State; got != tt.wantBreaker {
+				t.Errorf("breaker = %s, want %s", got, tt.wantBreaker)
+			}
+		})
+	}
+}
+
+func TestGetWithRetryOpenBreaker(t *testing.T) {
+	breaker := NewCircuitBreaker("test", 1, time.Hour)
+	breaker.Failure()
+	_, err := getWithRetry(context.Background(), http.DefaultClient, breaker, DefaultRetryPolicy, "http://127.0.0.1:0")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want %v", err, ErrCircuitOpen)
+	}
+}
@@ -0,0 +1,126 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Outcomes of a scheduled tick
+const (
+	ScheduleQueued  = "queued"
+	ScheduleSkipped = "skipped"
+	ScheduleFailed  = "failed"
+)
+
+// SchedulerStatus is a point-in-time view of the refresh scheduler
+type SchedulerStatus struct {
+	Schedule    string
+	NextRun     time.Time
+	LastRun     time.Time
+	LastJobID   int64
+	LastOutcome string
+}
+
+// RefreshScheduler periodically queues a refresh job. Every replica runs
+// one, so a tick is skipped when a refresh is already queued or running, or
+// when the cluster lock is held anywhere: only the replica that takes the
+// lock queues a job.
+type RefreshScheduler struct {
+	jobs     *RefreshJobService
+	schedule Schedule
+	jitter   time.Duration
+	opts     RefreshOptions
+
+	mu     sync.Mutex
+	status SchedulerStatus
+}
+
+// NewRefreshScheduler creates a scheduler that queues refreshes with opts
+func NewRefreshScheduler(jobs *RefreshJobService, schedule Schedule, jitter time.Duration, opts RefreshOptions) *RefreshScheduler {
+	return &RefreshScheduler{
+		jobs:     jobs,
+		schedule: schedule,
+		jitter:   jitter,
+		opts:     opts,
+		status:   SchedulerStatus{Schedule: fmt.Sprint(schedule)},
+	}
+}
+
+// Start runs the scheduler loop until ctx is cancelled
+func (s *RefreshScheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := s.nextRun(time.Now())
+			if next.IsZero() {
+				log.Printf("refresh scheduler: schedule %s never fires, stopping", s.status.Schedule)
+				return
+			}
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				s.tick(ctx)
+			}
+		}
+	}()
+}
+
+// Status returns the last and next scheduled runs
+func (s *RefreshScheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// nextRun computes the next activation with jitter applied and records it
+func (s *RefreshScheduler) nextRun(now time.Time) time.Time {
+	next := s.schedule.Next(now)
+	if !next.IsZero() && s.jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(s.jitter))))
+	}
+	s.mu.Lock()
+	s.status.NextRun = next
+	s.mu.Unlock()
+	return next
+}
+
+func (s *RefreshScheduler) tick(ctx context.Context) {
+	s.mu.Lock()
+	s.status.LastRun = time.Now()
+	s.mu.Unlock()
+
+	if s.jobs.Busy() {
+		log.Println("refresh scheduler: refresh already in progress, skipping")
+		s.setOutcome(0, ScheduleSkipped)
+		return
+	}
+	job, err := s.jobs.TryEnqueue(ctx, s.opts)
+	if errors.Is(err, ErrRefreshInProgress) {
+		log.Printf("refresh scheduler: %v, skipping", err)
+		s.setOutcome(0, ScheduleSkipped)
+		return
+	}
+	if err != nil {
+		log.Printf("refresh scheduler: could not queue refresh: %v", err)
+		s.setOutcome(0, ScheduleFailed)
+		return
+	}
+	log.Printf("refresh scheduler: queued refresh job %d", job.ID)
+	s.setOutcome(job.ID, ScheduleQueued)
+}
+
+func (s *RefreshScheduler) setOutcome(jobID int64, outcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jobID != 0 {
+		s.status.LastJobID = jobID
+	}
+	s.status.LastOutcome = outcome
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/franzego/stage02/models"
+)
+
+// Names of the built-in refresh sources
+const (
+	SourceLive = "live"
+	SourceFile = "file"
+)
+
+// ErrUnknownSource is returned when a refresh asks for an unregistered source
+var ErrUnknownSource = errors.New("unknown refresh source")
+
+// Sources pairs the providers a refresh reads from
+type Sources struct {
+	Countries CountrySource
+	Rates     RateSource
+}
+
+// CountrySource supplies the raw country records used by a refresh. The
+// scoped fetches back targeted refreshes and return an empty slice, not an
+// error, when nothing matches.
+type CountrySource interface {
+	FetchAllCountries(ctx context.Context) ([]models.CountryData, error)
+	FetchCountriesByName(ctx context.Context, name string) ([]models.CountryData, error)
+	FetchCountriesByRegion(ctx context.Context, region string) ([]models.CountryData, error)
+}
+
+// RateSource supplies a set of exchange rates keyed by currency code
+type RateSource interface {
+	FetchExchangeRate(ctx context.Context) (models.RateSet, error)
+}
+
+// breakerReporter is implemented by sources that guard calls with circuit
+// breakers
+type breakerReporter interface {
+	Breakers() []BreakerSnapshot
+}
+
+var (
+	_ breakerReporter = (*ExternalApi)(nil)
+	_ CountrySource   = (*ExternalApi)(nil)
+	_ RateSource      = (*ExternalApi)(nil)
+	_ CountrySource   = (*FileSource)(nil)
+	_ RateSource      = (*FileSource)(nil)
+	_ breakerReporter = (*FrankfurterSource)(nil)
+	_ RateSource      = (*FrankfurterSource)(nil)
+	_ breakerReporter = (*RateFailover)(nil)
+	_ RateSource      = (*RateFailover)(nil)
+)
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/franzego/stage02/models"
+)
+
+// ValidationRules decide which upstream records are stored and which are
+// quarantined
+type ValidationRules struct {
+	// MinPopulation and MaxPopulation bound the accepted population;
+	// MaxPopulation 0 means no upper bound
+	MinPopulation int64
+	MaxPopulation int64
+	// MaxNameLength is the longest accepted name, in characters
+	MaxNameLength int
+	// CurrencyCode must match every currency code a record lists
+	CurrencyCode *regexp.Regexp
+	// RequireRegion rejects records without a region
+	RequireRegion bool
+}
+
+// DefaultValidationRules reject the obviously broken records: an empty or
+// oversized name, a negative or impossible population, and currency codes
+// that are not ISO 4217 shaped
+var DefaultValidationRules = ValidationRules{
+	MinPopulation: 0,
+	MaxPopulation: 10_000_000_000,
+	MaxNameLength: 255,
+	CurrencyCode:  regexp.MustCompile(`^[A-Z]{3}$`),
+	RequireRegion: false,
+}
+
+// ISO 3166-1 code shapes; upstream records may leave any of them out
+var (
+	alpha2Pattern  = regexp.MustCompile(`^[A-Za-z]{2}$`)
+	alpha3Pattern  = regexp.MustCompile(`^[A-Za-z]{3}$`)
+	numericPattern = regexp.MustCompile(`^[0-9]{3}$`)
+)
+
+// Validate lists every rule a record breaks; nil means it is valid
+func (r ValidationRules) Validate(country models.CountryData) []string {
+	var reasons []string
+	name := strings.TrimSpace(country.Name)
+	if name == "" {
+		reasons = append(reasons, "name is empty")
+	} else if r.MaxNameLength > 0 && utf8.RuneCountInString(name) > r.MaxNameLength {
+		reasons = append(reasons, fmt.Sprintf("name is longer than %d characters", r.MaxNameLength))
+	}
+
+	if country.Population < r.MinPopulation {
+		reasons = append(reasons, fmt.Sprintf("population %d is below %d", country.Population, r.MinPopulation))
+	}
+	if r.MaxPopulation > 0 && country.Population > r.MaxPopulation {
+		reasons = append(reasons, fmt.Sprintf("population %d is above %d", country.Population, r.MaxPopulation))
+	}
+
+	if r.RequireRegion && strings.TrimSpace(country.Region) == "" {
+		reasons = append(reasons, "region is empty")
+	}
+
+	// malformed codes would not fit their columns, so they are always checked
+	reasons = append(reasons, codeShapeReasons(country)...)
+
+	if country.Area != nil && *country.Area < 0 {
+		reasons = append(reasons, fmt.Sprintf("area %g is negative", *country.Area))
+	}
+	if len(country.Latlng) == 2 && (math.Abs(country.Latlng[0]) > 90 || math.Abs(country.Latlng[1]) > 180) {
+		reasons = append(reasons, fmt.Sprintf("latlng %v is out of range", country.Latlng))
+	}
+	if len(country.CapitalLatlng) == 2 && (math.Abs(country.CapitalLatlng[0]) > 90 || math.Abs(country.CapitalLatlng[1]) > 180) {
+		reasons = append(reasons, fmt.Sprintf("capital latlng %v is out of range", country.CapitalLatlng))
+	}
+
+	if r.CurrencyCode != nil {
+		for _, cur := range country.Currencies {
+			// currencies without a code are dropped later, not rejected
+			if cur.Code != "" && !r.CurrencyCode.MatchString(cur.Code) {
+				reasons = append(reasons, fmt.Sprintf("currency code %q does not match %s", cur.Code, r.CurrencyCode))
+			}
+		}
+	}
+	return reasons
+}
+
+// codeShapeReasons lists the ISO 3166-1 codes of a record that are not
+// shaped like one
+func codeShapeReasons(country models.CountryData) []string {
+	var reasons []string
+	for _, code := range []struct {
+		field, value string
+		pattern      *regexp.Regexp
+	}{
+		{"alpha2Code", country.Alpha2Code, alpha2Pattern},
+		{"alpha3Code", country.Alpha3Code, alpha3Pattern},
+		{"numericCode", country.NumericCode, numericPattern},
+	} {
+		if v := strings.TrimSpace(code.value); v != "" && !code.pattern.MatchString(v) {
+			reasons = append(reasons, fmt.Sprintf("%s %q is not an ISO 3166-1 code", code.field, code.value))
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/franzego/stage02/models"
+)
+
+func validCountry() models.CountryData {
+	return models.CountryData{
+		Name:        "Ghana",
+		Region:      "Africa",
+		Population:  31072945,
+		Alpha2Code:  "GH",
+		Alpha3Code:  "GHA",
+		NumericCode: "288",
+		Latlng:      []float64{8, -2},
+		Currencies:  []models.Currency{{Code: "GHS"}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	negative := -1.0
+	tests := []struct {
+		name   string
+		rules  ValidationRules
+		modify func(c *models.CountryData)
+		want   []string
+	}{
+		{name: "valid", rules: DefaultValidationRules},
+		{
+			name:   "empty name",
+			rules:  DefaultValidationRules,
+			modify: func(c *models.CountryData) { c.Name = "  " },
+			want:   []string{"name is empty"},
+		},
+		{
+			name:   "long name counts characters",
+			rules:  ValidationRules{MaxNameLength: 3},
+			modify: func(c *models.CountryData) { c.Name = "Gháná" },
+			want:   []string{"name is longer than 3 characters"},
+		},
+		{
+			name:   "population bounds",
+			rules:  ValidationRules{MinPopulation: 1, MaxPopulation: 100},
+			modify: func(c *models.CountryData) { c.Population = 0 },
+			want:   []string{"population 0 is below 1"},
+		},
+		{
+			name:  "population above the maximum",
+			rules: ValidationRules{MaxPopulation: 100},
+			want:  []string{"population 31072945 is above 100"},
+		},
+		{
+			name:   "region required",
+			rules:  ValidationRules{RequireRegion: true},
+			modify: func(c *models.CountryData) { c.Region = "" },
+			want:   []string{"region is empty"},
+		},
+		{
+			name:  "malformed codes without rules",
+			rules: ValidationRules{},
+			modify: func(c *models.CountryData) {
+				c.Alpha2Code, c.Alpha3Code, c.NumericCode = "GHA", "G1A", "28"
+			},
+			want: []string{
+				`alpha2Code "GHA" is not an ISO 3166-1 code`,
+				`alpha3Code "G1A" is not an ISO 3166-1 code`,
+				`numericCode "28" is not an ISO 3166-1 code`,
+			},
+		},
+		{
+			name:   "missing codes are fine",
+			rules:  DefaultValidationRules,
+			modify: func(c *models.CountryData) { c.Alpha2Code, c.Alpha3Code, c.NumericCode = "", "", "" },
+		},
+		{
+			name:  "geography out of range",
+			rules: ValidationRules{},
+			modify: func(c *models.CountryData) {
+				c.Area = &negative
+				c.Latlng = []float64{91, 0}
+				c.CapitalLatlng = []float64{0, -181}
+			},
+			want: []string{"area -1 is negative", "latlng [91 0] is out of range", "capital latlng [0 -181] is out of range"},
+		},
+		{
+			name:  "currency codes",
+			rules: ValidationRules{CurrencyCode: regexp.MustCompile(`^[A-Z]{3}$`)},
+			modify: func(c *models.CountryData) {
+				c.Currencies = []models.Currency{{Code: "ghs"}, {Code: ""}, {Code: "USD"}}
+			},
+			want: []string{`currency code "ghs" does not match ^[A-Z]{3}$`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			country := validCountry()
+			if tt.modify != nil {
+				tt.modify(&country)
+			}
+			if got := tt.rules.Validate(country); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimCodes(t *testing.T) {
+	claimed := map[string]string{}
+	ghana := validCountry()
+	if reasons := claimCodes(claimed, ghana); reasons != nil {
+		t.Fatalf("first claim: %q", reasons)
+	}
+	// the same country may appear twice without clashing with itself
+	if reasons := claimCodes(claimed, ghana); reasons != nil {
+		t.Fatalf("repeated claim: %q", reasons)
+	}
+
+	impostor := models.CountryData{Name: "Gold Coast", Alpha2Code: "gh", Alpha3Code: "GCO", NumericCode: "288"}
+	reasons := claimCodes(claimed, impostor)
+	if len(reasons) != 2 || !strings.Contains(reasons[0], "alpha2Code GH is already used by Ghana") ||
+		!strings.Contains(reasons[1], "numericCode 288 is already used by Ghana") {
+		t.Errorf("claimCodes = %q", reasons)
+	}
+	if claimed["alpha3Code:GCO"] != "Gold Coast" {
+		t.Errorf("unclaimed code was not recorded: %v", claimed)
+	}
+}
+
+func TestCodeShapeReasons(t *testing.T) {
+	if reasons := codeShapeReasons(validCountry()); reasons != nil {
+		t.Errorf("valid codes: %q", reasons)
+	}
+	if reasons := codeShapeReasons(models.CountryData{Name: "Nowhere"}); reasons != nil {
+		t.Errorf("missing codes: %q", reasons)
+	}
+	bad := models.CountryData{Name: "Ghana", Alpha2Code: "GHA", Alpha3Code: "G1A", NumericCode: "2888"}
+	want := []string{
+		`alpha2Code "GHA" is not an ISO 3166-1 code`,
+		`alpha3Code "G1A" is not an ISO 3166-1 code`,
+		`numericCode "2888" is not an ISO 3166-1 code`,
+	}
+	if got := codeShapeReasons(bad); !reflect.DeepEqual(got, want) {
+		t.Errorf("codeShapeReasons = %q, want %q", got, want)
+	}
+}
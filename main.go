@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	db "github.com/franzego/stage02/db/sqlc"
 	"github.com/franzego/stage02/internal"
 	"github.com/franzego/stage02/internal/database"
+	services "github.com/franzego/stage02/internal/services"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
@@ -34,15 +41,109 @@ func main() {
 	}
 	// Initialize queries
 	queries := db.New(dbconn)
-	handle := internal.NewCountryHandler(queries)
+	api, err := buildExternalApi()
+	if err != nil {
+		log.Fatalf("Invalid upstream configuration: %v", err)
+	}
+	snapshotFormat := getEnv("COUNTRIES_FILE_FORMAT", getEnv("RESTCOUNTRIES_VERSION", services.FormatV2))
+	if err := services.ValidateFormat(snapshotFormat); err != nil {
+		log.Fatalf("Invalid COUNTRIES_FILE_FORMAT: %v", err)
+	}
+	snapshot := services.NewFileSource(
+		getEnv("COUNTRIES_FILE", "data/countries.json"),
+		getEnv("RATES_FILE", "data/rates.json"),
+	).WithFormat(snapshotFormat)
+	rates, err := buildRateFailover(queries, api, snapshot)
+	if err != nil {
+		log.Fatalf("Invalid rate provider configuration: %v", err)
+	}
+	countryService := services.NewCountryService(dbconn, queries, api, rates)
+	policy := services.DefaultRefreshPolicy
+	if policy.BatchSize, err = getEnvInt("REFRESH_BATCH_SIZE", policy.BatchSize); err != nil {
+		log.Fatalf("Invalid refresh policy: %v", err)
+	}
+	if policy.MaxBadRows, err = getEnvInt("REFRESH_MAX_BAD_ROWS", policy.MaxBadRows); err != nil {
+		log.Fatalf("Invalid refresh policy: %v", err)
+	}
+	if policy.MaxBadRows < 0 {
+		log.Fatalf("Invalid refresh policy: REFRESH_MAX_BAD_ROWS must not be negative, got %d", policy.MaxBadRows)
+	}
+	if policy.MaxRemovalFraction, err = getEnvFloat("RECONCILE_MAX_REMOVAL_FRACTION", policy.MaxRemovalFraction); err != nil {
+		log.Fatalf("Invalid refresh policy: %v", err)
+	}
+	if policy.RatesMaxAge, err = getEnvDuration("RATES_CACHE_MAX_AGE", policy.RatesMaxAge); err != nil {
+		log.Fatalf("Invalid refresh policy: %v", err)
+	}
+	countryService.SetRefreshPolicy(policy)
+	estimator, err := buildGDPEstimator()
+	if err != nil {
+		log.Fatalf("Invalid gdp configuration: %v", err)
+	}
+	countryService.SetGDPEstimator(estimator)
+	countryService.SetImportDir(getEnv("GDP_IMPORT_DIR", "data"))
+	rules, err := buildValidationRules()
+	if err != nil {
+		log.Fatalf("Invalid validation rules: %v", err)
+	}
+	countryService.SetValidationRules(rules)
+	flags, err := buildFlagMirror(queries)
+	if err != nil {
+		log.Fatalf("Invalid flag mirror configuration: %v", err)
+	}
+	countryService.SetFlagMirror(flags)
+	if secret := os.Getenv("CURSOR_SECRET"); secret != "" {
+		countryService.SetCursorSecret([]byte(secret))
+	} else {
+		log.Println("CURSOR_SECRET not set, page cursors will not survive a restart")
+	}
+	// `import-gdp <file.csv>` loads reported GDP figures and exits
+	if len(os.Args) > 1 && os.Args[1] == "import-gdp" {
+		runImportGDP(countryService, os.Args[2:])
+		return
+	}
+	countryService.RegisterSource(services.SourceFile, snapshot, snapshot)
+	if err := countryService.SetDefaultSource(getEnv("REFRESH_SOURCE", services.SourceLive)); err != nil {
+		log.Fatalf("Invalid REFRESH_SOURCE: %v", err)
+	}
+	lockTimeout, err := getEnvDuration("REFRESH_LOCK_TIMEOUT", services.DefaultLockTimeout)
+	if err != nil {
+		log.Fatalf("Invalid REFRESH_LOCK_TIMEOUT: %v", err)
+	}
+	lock := services.NewClusterLock(dbconn, services.DefaultRefreshLockName, lockTimeout)
+	jobs := services.NewRefreshJobService(queries, countryService, lock)
+	if err := jobs.RecoverInterrupted(context.Background()); err != nil {
+		log.Fatalf("Failed to recover refresh jobs: %v", err)
+	}
+	scheduler, err := buildScheduler(jobs)
+	if err != nil {
+		log.Fatalf("Invalid refresh schedule: %v", err)
+	}
+	if scheduler != nil {
+		scheduler.Start(context.Background())
+		log.Printf("✓ Scheduled refresh enabled (%s)", scheduler.Status().Schedule)
+	}
+	handle := internal.NewCountryHandler(queries, countryService, jobs, scheduler)
 	r := gin.Default()
 	// Routes
 	r.POST("/countries/refresh", handle.RefreshCountries)
+	r.POST("/countries/:name/refresh", handle.RefreshCountry)
+	r.GET("/refresh/jobs/:id", handle.GetRefreshJob)
+	r.GET("/refresh/jobs/:id/changes", handle.GetRefreshJobChanges)
 	r.GET("/countries", handle.GetAllCountries)
 	r.GET("/countries/:name", handle.GetCountryName)
+	r.GET("/countries/:name/history", handle.GetCountryHistory)
+	r.GET("/countries/:name/neighbors", handle.GetNeighbors)
+	r.GET("/countries/:name/flag", handle.GetCountryFlag)
+	r.GET("/countries/path", handle.GetBorderPath)
+	r.GET("/countries/nearest", handle.GetNearestCountries)
+	r.GET("/countries/distance", handle.GetDistance)
 	r.DELETE("/countries/:name", handle.DeleteCountryName)
 	r.GET("/status", handle.GetStatus)
 	r.GET("/countries/image", handle.GetImage)
+	r.GET("/currencies/:code/rates", handle.GetCurrencyRates)
+	r.POST("/gdp/import", handle.ImportReportedGDP)
+	r.GET("/admin/quarantine", handle.GetQuarantine)
+	r.POST("/admin/quarantine/:id/accept", handle.AcceptQuarantine)
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
@@ -75,6 +176,147 @@ func buildDSN() string {
 		user, password, host, port, database)
 }
 
+// buildExternalApi configures the live upstream client from the
+// environment: restcountries schema, endpoint overrides, retry policy and
+// circuit breakers
+func buildExternalApi() (*services.ExternalApi, error) {
+	policy, threshold, cooldown, err := upstreamPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	format := getEnv("RESTCOUNTRIES_VERSION", services.FormatV2)
+	if err := services.ValidateFormat(format); err != nil {
+		return nil, fmt.Errorf("RESTCOUNTRIES_VERSION: %w", err)
+	}
+
+	return services.NewExternalService().
+		WithCountriesFormat(format).
+		WithURLs(os.Getenv("COUNTRIES_API_URL"), os.Getenv("RATES_API_URL")).
+		WithRetryPolicy(policy).
+		WithBreakers(threshold, cooldown), nil
+}
+
+// upstreamPolicy reads the retry and circuit breaker settings shared by
+// every upstream API
+func upstreamPolicy() (services.RetryPolicy, int, time.Duration, error) {
+	policy := services.DefaultRetryPolicy
+	var err error
+	if policy.MaxRetries, err = getEnvInt("UPSTREAM_MAX_RETRIES", policy.MaxRetries); err != nil {
+		return policy, 0, 0, err
+	}
+	if policy.BaseDelay, err = getEnvDuration("UPSTREAM_BACKOFF_BASE", policy.BaseDelay); err != nil {
+		return policy, 0, 0, err
+	}
+	if policy.MaxDelay, err = getEnvDuration("UPSTREAM_BACKOFF_MAX", policy.MaxDelay); err != nil {
+		return policy, 0, 0, err
+	}
+	threshold, err := getEnvInt("BREAKER_THRESHOLD", services.DefaultBreakerThreshold)
+	if err != nil {
+		return policy, 0, 0, err
+	}
+	cooldown, err := getEnvDuration("BREAKER_COOLDOWN", services.DefaultBreakerCooldown)
+	if err != nil {
+		return policy, 0, 0, err
+	}
+	return policy, threshold, cooldown, nil
+}
+
+// buildRateFailover reads RATE_PROVIDERS, a comma separated list of er-api,
+// frankfurter and file tried in that order, and RATE_MAX_DEVIATION
+func buildRateFailover(queries *db.Queries, api *services.ExternalApi, snapshot *services.FileSource) (*services.RateFailover, error) {
+	policy, threshold, cooldown, err := upstreamPolicy()
+	if err != nil {
+		return nil, err
+	}
+	var providers []services.RateProvider
+	for _, name := range strings.Split(getEnv("RATE_PROVIDERS", "er-api,frankfurter,file"), ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			continue
+		case services.ProviderERAPI:
+			providers = append(providers, services.RateProvider{Name: name, Source: api})
+		case services.ProviderFrankfurter:
+			frankfurter := services.NewFrankfurterSource(os.Getenv("FRANKFURTER_URL")).
+				WithRetryPolicy(policy).
+				WithBreaker(threshold, cooldown)
+			providers = append(providers, services.RateProvider{Name: name, Source: frankfurter})
+		case services.ProviderFile:
+			providers = append(providers, services.RateProvider{Name: name, Source: snapshot})
+		default:
+			return nil, fmt.Errorf("RATE_PROVIDERS: unknown provider %q", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("RATE_PROVIDERS: no providers configured")
+	}
+	maxDeviation, err := getEnvFloat("RATE_MAX_DEVIATION", services.DefaultMaxRateDeviation)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewRateFailover(queries, providers...).WithMaxDeviation(maxDeviation), nil
+}
+
+// buildFlagMirror reads FLAG_MIRROR (on by default) and FLAG_CONCURRENCY.
+// Flags are stored under CACHE_DIR/flags; nil means mirroring is off.
+func buildFlagMirror(queries *db.Queries) (*services.FlagMirror, error) {
+	enabled, err := strconv.ParseBool(getEnv("FLAG_MIRROR", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("FLAG_MIRROR: %w", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	policy, threshold, cooldown, err := upstreamPolicy()
+	if err != nil {
+		return nil, err
+	}
+	concurrency, err := getEnvInt("FLAG_CONCURRENCY", services.DefaultFlagConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(getEnv("CACHE_DIR", "cache"), "flags")
+	return services.NewFlagMirror(queries, dir).
+		WithRetryPolicy(policy).
+		WithBreaker(threshold, cooldown).
+		WithConcurrency(concurrency), nil
+}
+
+// buildScheduler reads REFRESH_CRON or REFRESH_INTERVAL (plus optional
+// REFRESH_JITTER and REFRESH_RECONCILE) and returns nil when neither is set
+func buildScheduler(jobs *services.RefreshJobService) (*services.RefreshScheduler, error) {
+	var schedule services.Schedule
+	if expr := os.Getenv("REFRESH_CRON"); expr != "" {
+		cron, err := services.ParseCron(expr)
+		if err != nil {
+			return nil, err
+		}
+		schedule = cron
+	} else if interval := os.Getenv("REFRESH_INTERVAL"); interval != "" {
+		every, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("REFRESH_INTERVAL: %w", err)
+		}
+		if every <= 0 {
+			return nil, fmt.Errorf("REFRESH_INTERVAL must be positive")
+		}
+		schedule = services.IntervalSchedule{Every: every}
+	} else {
+		return nil, nil
+	}
+
+	jitter, err := getEnvDuration("REFRESH_JITTER", 0)
+	if err != nil {
+		return nil, err
+	}
+	opts := services.RefreshOptions{Reconcile: os.Getenv("REFRESH_RECONCILE")}
+	if err := services.ValidateReconcile(opts.Reconcile); err != nil {
+		return nil, fmt.Errorf("REFRESH_RECONCILE: %w", err)
+	}
+	return services.NewRefreshScheduler(jobs, schedule, jitter, opts), nil
+}
+
 // getEnv gets env variable with fallback
 // just iincase
 func getEnv(key, fallback string) string {
@@ -84,3 +326,110 @@ func getEnv(key, fallback string) string {
 	return fallback
 
 }
+
+// runImportGDP imports a reported GDP CSV from the command line
+func runImportGDP(countryService *services.CountryService, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: %s import-gdp <file.csv>", os.Args[0])
+	}
+	result, err := countryService.ImportReportedGDPFile(context.Background(), args[0])
+	if err != nil {
+		log.Fatalf("GDP import failed: %v", err)
+	}
+	log.Printf("✓ Imported reported GDP: %d rows, %d countries updated", result.Rows, result.Updated)
+	if len(result.Unmatched) > 0 {
+		log.Printf("No country matched %d rows: %s", len(result.Unmatched), strings.Join(result.Unmatched, ", "))
+	}
+}
+
+// buildGDPEstimator picks the GDP estimation strategy from GDP_STRATEGY
+// (random, fixed or file). GDP_SEED seeds the random model and
+// GDP_MULTIPLIER is the fixed multiplier, also used for countries missing
+// from GDP_MULTIPLIERS_FILE.
+func buildGDPEstimator() (services.GDPEstimator, error) {
+	seed, err := getEnvInt("GDP_SEED", 0)
+	if err != nil {
+		return nil, err
+	}
+	multiplier, err := getEnvFloat("GDP_MULTIPLIER", services.DefaultGDPMultiplier)
+	if err != nil {
+		return nil, err
+	}
+	if multiplier <= 0 {
+		return nil, fmt.Errorf("GDP_MULTIPLIER must be positive, got %v", multiplier)
+	}
+	return services.NewGDPEstimator(
+		getEnv("GDP_STRATEGY", services.GDPRandom),
+		int64(seed),
+		multiplier,
+		os.Getenv("GDP_MULTIPLIERS_FILE"),
+	)
+}
+
+// buildValidationRules reads the upstream record rules, starting from the
+// defaults
+func buildValidationRules() (services.ValidationRules, error) {
+	rules := services.DefaultValidationRules
+	minPopulation, err := getEnvInt("VALIDATION_MIN_POPULATION", int(rules.MinPopulation))
+	if err != nil {
+		return rules, err
+	}
+	maxPopulation, err := getEnvInt("VALIDATION_MAX_POPULATION", int(rules.MaxPopulation))
+	if err != nil {
+		return rules, err
+	}
+	rules.MinPopulation, rules.MaxPopulation = int64(minPopulation), int64(maxPopulation)
+	if rules.MaxNameLength, err = getEnvInt("VALIDATION_MAX_NAME_LENGTH", rules.MaxNameLength); err != nil {
+		return rules, err
+	}
+	if pattern := os.Getenv("VALIDATION_CURRENCY_PATTERN"); pattern != "" {
+		if rules.CurrencyCode, err = regexp.Compile(pattern); err != nil {
+			return rules, fmt.Errorf("VALIDATION_CURRENCY_PATTERN: %w", err)
+		}
+	}
+	if v := os.Getenv("VALIDATION_REQUIRE_REGION"); v != "" {
+		if rules.RequireRegion, err = strconv.ParseBool(v); err != nil {
+			return rules, fmt.Errorf("VALIDATION_REQUIRE_REGION: %w", err)
+		}
+	}
+	return rules, nil
+}
+
+// getEnvInt reads an integer env variable with fallback
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return n, nil
+}
+
+// getEnvDuration reads a duration env variable (e.g. "30s") with fallback
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return d, nil
+}
+
+// getEnvFloat reads a float env variable with fallback
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return f, nil
+}
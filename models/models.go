@@ -1,5 +1,10 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type CountryData struct {
 	Name        string     `json:"name"`
 	Capital     string     `json:"capital"`
@@ -8,6 +13,68 @@ type CountryData struct {
 	Flag        string     `json:"flag"`
 	Currencies  []Currency `json:"currencies"`
 	Independent bool       `json:"independent"`
+	Alpha2Code  string     `json:"alpha2Code"`
+	Alpha3Code  string     `json:"alpha3Code"`
+	NumericCode string     `json:"numericCode"`
+	Subregion   string     `json:"subregion"`
+	Area        *float64   `json:"area"`
+	Latlng      []float64  `json:"latlng"`
+	Languages   []Language `json:"languages"`
+	Timezones   []string   `json:"timezones"`
+	// CallingCodes are international dialling prefixes without the "+"
+	CallingCodes []string `json:"callingCodes"`
+	// Borders lists the alpha-3 codes of neighbouring countries
+	Borders []string `json:"borders"`
+	// CapitalLatlng is only supplied by v3.1, as capitalInfo
+	CapitalLatlng []float64 `json:"capitalLatlng,omitempty"`
+}
+
+// CountryDataV3 is a restcountries v3.1 record, decoded and then mapped
+// onto CountryData
+type CountryDataV3 struct {
+	Name struct {
+		Common   string `json:"common"`
+		Official string `json:"official"`
+	} `json:"name"`
+	Capital    []string `json:"capital"`
+	Region     string   `json:"region"`
+	Population int64    `json:"population"`
+	Flags      struct {
+		PNG string `json:"png"`
+		SVG string `json:"svg"`
+	} `json:"flags"`
+	Currencies  map[string]CurrencyV3 `json:"currencies"`
+	Independent bool                  `json:"independent"`
+	Cca2        string                `json:"cca2"`
+	Cca3        string                `json:"cca3"`
+	Ccn3        string                `json:"ccn3"`
+	Subregion   string                `json:"subregion"`
+	Area        *float64              `json:"area"`
+	Latlng      []float64             `json:"latlng"`
+	// Languages maps ISO 639-3 codes to names
+	Languages   map[string]string `json:"languages"`
+	Timezones   []string          `json:"timezones"`
+	Idd         IddV3             `json:"idd"`
+	Borders     []string          `json:"borders"`
+	CapitalInfo struct {
+		Latlng []float64 `json:"latlng"`
+	} `json:"capitalInfo"`
+}
+
+// IddV3 is a v3.1 dialling prefix, split into a root such as "+3" and
+// suffixes such as "54"
+type IddV3 struct {
+	Root     string   `json:"root"`
+	Suffixes []string `json:"suffixes"`
+}
+type CurrencyV3 struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+}
+type Language struct {
+	Iso6391 string `json:"iso639_1"`
+	Iso6392 string `json:"iso639_2"`
+	Name    string `json:"name"`
 }
 type Currency struct {
 	Code      string `json:"code"`
@@ -15,30 +82,146 @@ type Currency struct {
 	SymbolUrl string `json:"symbol"`
 }
 type ExchangeRateResponse struct {
-	Result string             `json:"result"`
-	Rates  map[string]float64 `json:"rates"`
+	Result             string             `json:"result"`
+	BaseCode           string             `json:"base_code"`
+	TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+	Rates              map[string]float64 `json:"rates"`
+}
+
+// RateSet is one fetched set of exchange rates against a base currency
+type RateSet struct {
+	Base      string
+	Rates     map[string]float64
+	FetchedAt time.Time
+	// Provider names the rate provider the set came from
+	Provider string
+	// Carried marks the rates copied from the last stored set for
+	// currencies the provider did not quote; they are not stored again
+	Carried map[string]bool
 }
 type ProcessedCountry struct {
-	Name         string
-	Capital      string
-	Region       string
-	Population   int64
-	CurrencyCode *string  // nullable
-	ExchangeRate *float64 // nullable
-	EstimatedGDP *float64 // nullable
-	FlagURL      string
+	Name          string
+	Capital       string
+	Region        string
+	Population    int64
+	CurrencyCode  *string  // nullable
+	ExchangeRate  *float64 // nullable
+	EstimatedGDP  *float64 // nullable
+	FlagURL       string
+	Currencies    []Currency // every currency with a code, primary first
+	GDPMultiplier *float64   // nullable, set with EstimatedGDP
+	GDPStrategy   string
+	Alpha2Code    string // ISO 3166-1 codes, empty when unknown
+	Alpha3Code    string
+	NumericCode   string
+	Subregion     string
+	Area          *float64 // nullable
+	Latitude      *float64 // nullable, with Longitude
+	Longitude     *float64
+	Languages     []Language // every language with a code, in upstream order
+	Timezones     []string
+	CallingCodes  []string
+	Borders       []string
+	// nullable, the capital's coordinates when upstream knows them
+	CapitalLatitude  *float64
+	CapitalLongitude *float64
 }
 type CountryResponse struct {
-	ID              int64    `json:"id"`
-	Name            string   `json:"name"`
-	Capital         *string  `json:"capital,omitempty"`
-	Region          *string  `json:"region,omitempty"`
-	Population      int64    `json:"population"`
-	CurrencyCode    *string  `json:"currency_code,omitempty"`
-	ExchangeRate    *float64 `json:"exchange_rate,omitempty"`
-	EstimatedGDP    *float64 `json:"estimated_gdp,omitempty"`
-	FlagURL         *string  `json:"flag_url,omitempty"`
-	LastRefreshedAt string   `json:"last_refreshed_at,omitempty"`
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Alpha2Code       *string  `json:"alpha2_code,omitempty"`
+	Alpha3Code       *string  `json:"alpha3_code,omitempty"`
+	NumericCode      *string  `json:"numeric_code,omitempty"`
+	Capital          *string  `json:"capital,omitempty"`
+	Region           *string  `json:"region,omitempty"`
+	Subregion        *string  `json:"subregion,omitempty"`
+	Population       int64    `json:"population"`
+	Area             *float64 `json:"area,omitempty"`
+	Latitude         *float64 `json:"latitude,omitempty"`
+	Longitude        *float64 `json:"longitude,omitempty"`
+	CapitalLatitude  *float64 `json:"capital_latitude,omitempty"`
+	CapitalLongitude *float64 `json:"capital_longitude,omitempty"`
+	// DistanceKM is only set by the geospatial endpoints
+	DistanceKM      *float64           `json:"distance_km,omitempty"`
+	Timezones       []string           `json:"timezones,omitempty"`
+	CallingCodes    []string           `json:"calling_codes,omitempty"`
+	Borders         []string           `json:"borders,omitempty"`
+	CurrencyCode    *string            `json:"currency_code,omitempty"`
+	ExchangeRate    *float64           `json:"exchange_rate,omitempty"`
+	EstimatedGDP    *float64           `json:"estimated_gdp,omitempty"`
+	ReportedGDP     *float64           `json:"reported_gdp,omitempty"`
+	ReportedGDPYear *int32             `json:"reported_gdp_year,omitempty"`
+	GDPStrategy     *string            `json:"gdp_strategy,omitempty"`
+	GDPMultiplier   *float64           `json:"gdp_multiplier,omitempty"`
+	FlagURL         *string            `json:"flag_url,omitempty"`
+	LastRefreshedAt string             `json:"last_refreshed_at,omitempty"`
+	StaleSince      *string            `json:"stale_since,omitempty"`
+	Currencies      []CurrencyResponse `json:"currencies,omitempty"`
+	Languages       []LanguageResponse `json:"languages,omitempty"`
+}
+type CurrencyResponse struct {
+	Code   string  `json:"code"`
+	Name   *string `json:"name,omitempty"`
+	Symbol *string `json:"symbol,omitempty"`
+}
+type LanguageResponse struct {
+	Code    string  `json:"code"`
+	Iso6391 *string `json:"iso639_1,omitempty"`
+	Name    *string `json:"name,omitempty"`
+}
+type RateSeriesResponse struct {
+	Currency string              `json:"currency"`
+	Base     string              `json:"base"`
+	Interval string              `json:"interval"`
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Points   []RatePointResponse `json:"points"`
+}
+type RatePointResponse struct {
+	Timestamp string  `json:"timestamp"`
+	Rate      float64 `json:"rate"`
+}
+type CountryHistoryResponse struct {
+	Name     string                   `json:"name"`
+	Versions []CountryVersionResponse `json:"versions"`
+}
+type CountryVersionResponse struct {
+	ValidFrom string          `json:"valid_from"`
+	ValidTo   *string         `json:"valid_to"`
+	Country   CountryResponse `json:"country"`
+}
+type NeighborsResponse struct {
+	Name      string            `json:"name"`
+	Neighbors []CountryResponse `json:"neighbors"`
+}
+type BorderPathResponse struct {
+	From string            `json:"from"`
+	To   string            `json:"to"`
+	Hops int               `json:"hops"`
+	Path []CountryResponse `json:"path"`
+}
+type DistanceResponse struct {
+	From CountryResponse `json:"from"`
+	To   CountryResponse `json:"to"`
+	// DistanceKM is measured between centroids, CapitalDistanceKM between
+	// capitals; either is left out when coordinates are missing
+	DistanceKM        *float64 `json:"distance_km,omitempty"`
+	CapitalDistanceKM *float64 `json:"capital_distance_km,omitempty"`
+}
+type GDPImportResponse struct {
+	Rows      int      `json:"rows"`
+	Updated   int      `json:"updated"`
+	Unmatched []string `json:"unmatched"`
+}
+type QuarantineEntryResponse struct {
+	ID         int64           `json:"id"`
+	JobID      *int64          `json:"job_id,omitempty"`
+	Name       string          `json:"name"`
+	Reasons    []string        `json:"reasons"`
+	Record     json.RawMessage `json:"record"`
+	Status     string          `json:"status"`
+	CreatedAt  string          `json:"created_at"`
+	AcceptedAt *string         `json:"accepted_at,omitempty"`
 }
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -48,6 +231,69 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 type StatusResponse struct {
-	TotalCountries  int64       `json:"total_countries"`
-	LastRefreshedAt interface{} `json:"last_refreshed_at"`
+	TotalCountries  int64            `json:"total_countries"`
+	LastRefreshedAt interface{}      `json:"last_refreshed_at"`
+	RateProvider    *string          `json:"rate_provider,omitempty"`
+	RateProviders   []string         `json:"rate_providers,omitempty"`
+	Scheduler       *SchedulerStatus `json:"scheduler,omitempty"`
+	Upstreams       []UpstreamStatus `json:"upstreams,omitempty"`
+}
+type UpstreamStatus struct {
+	Name                string  `json:"name"`
+	CircuitState        string  `json:"circuit_state"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	OpenedAt            *string `json:"opened_at,omitempty"`
+}
+type SchedulerStatus struct {
+	Schedule    string  `json:"schedule"`
+	NextRunAt   *string `json:"next_run_at,omitempty"`
+	LastRunAt   *string `json:"last_run_at,omitempty"`
+	LastJobID   *int64  `json:"last_job_id,omitempty"`
+	LastOutcome *string `json:"last_outcome,omitempty"`
+}
+type RefreshAcceptedResponse struct {
+	Message   string `json:"message"`
+	JobID     int64  `json:"job_id"`
+	StatusURL string `json:"status_url"`
+}
+type RefreshConflictResponse struct {
+	Error   string              `json:"error"`
+	Details string              `json:"details"`
+	Job     *RefreshJobResponse `json:"job,omitempty"`
+}
+type RefreshJobResponse struct {
+	ID                   int64          `json:"id"`
+	Status               string         `json:"status"`
+	Source               string         `json:"source"`
+	Scope                *string        `json:"scope,omitempty"`
+	RateProvider         *string        `json:"rate_provider,omitempty"`
+	CountriesProcessed   int32          `json:"countries_processed"`
+	CountriesFailed      int32          `json:"countries_failed"`
+	CountriesQuarantined int32          `json:"countries_quarantined"`
+	Error                *string        `json:"error,omitempty"`
+	CreatedAt            string         `json:"created_at"`
+	StartedAt            *string        `json:"started_at,omitempty"`
+	FinishedAt           *string        `json:"finished_at,omitempty"`
+	DurationMs           *int64         `json:"duration_ms,omitempty"`
+	Changes              *ChangeSummary `json:"changes,omitempty"`
+}
+type ChangeSummary struct {
+	Added    int32 `json:"added"`
+	Removed  int32 `json:"removed"`
+	Modified int32 `json:"modified"`
+}
+type RefreshChangesResponse struct {
+	JobID   int64                   `json:"job_id"`
+	Summary ChangeSummary           `json:"summary"`
+	Changes []CountryChangeResponse `json:"changes"`
+}
+type CountryChangeResponse struct {
+	Country    string                `json:"country"`
+	ChangeType string                `json:"change_type"`
+	Fields     []FieldChangeResponse `json:"fields,omitempty"`
+}
+type FieldChangeResponse struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old_value"`
+	NewValue *string `json:"new_value"`
 }